@@ -5,9 +5,12 @@ go 1.18
 require github.com/gorilla/mux v1.8.0
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/badoux/checkmail v1.2.1
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/joho/godotenv v1.4.0
 	golang.org/x/crypto v0.0.0-20220411220226-7b82a4e95df4
 )
+
+require golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
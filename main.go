@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"social-network/src/authentication"
 	"social-network/src/config"
 	"social-network/src/router"
+	"social-network/src/trending"
 )
 
 func main() {
 	config.Load()
+	if error := authentication.LoadKeys(); error != nil {
+		log.Fatal(error)
+	}
+	trending.Start()
 	r := router.Generate()
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Port), r))
 }
@@ -1,8 +1,8 @@
-insert into users (name, nick, email, password)
+insert into users (name, nick, email, password, email_verified_at, role)
 values
-("Usuário 1", "usuario_1", "usuario1@gmail.com", "$2a$10$0iGYlKCAYTyJV/vC6nLGgeWFwD6AhSkWLsVRO/.M4lNK8OtIkfggy"), -- usuario1
-("Usuário 2", "usuario_2", "usuario2@gmail.com", "$2a$10$0iGYlKCAYTyJV/vC6nLGgeWFwD6AhSkWLsVRO/.M4lNK8OtIkfggy"), -- usuario2
-("Usuário 3", "usuario_3", "usuario3@gmail.com", "$2a$10$0iGYlKCAYTyJV/vC6nLGgeWFwD6AhSkWLsVRO/.M4lNK8OtIkfggy"); -- usuario3
+("Usuário 1", "usuario_1", "usuario1@gmail.com", "$2a$10$0iGYlKCAYTyJV/vC6nLGgeWFwD6AhSkWLsVRO/.M4lNK8OtIkfggy", now(), "admin"), -- usuario1
+("Usuário 2", "usuario_2", "usuario2@gmail.com", "$2a$10$0iGYlKCAYTyJV/vC6nLGgeWFwD6AhSkWLsVRO/.M4lNK8OtIkfggy", now(), "user"), -- usuario2
+("Usuário 3", "usuario_3", "usuario3@gmail.com", "$2a$10$0iGYlKCAYTyJV/vC6nLGgeWFwD6AhSkWLsVRO/.M4lNK8OtIkfggy", now(), "user"); -- usuario3
 
 insert into followers(user_id, follower_id)
 values
@@ -12,6 +12,69 @@ values
 
 insert into posts(title, content, author_id)
 values
-("Publicação do Usuário 1", "Essa é a publicação do usuário 1! Oba!", 1),
-("Publicação do Usuário 2", "Essa é a publicação do usuário 2! Oba!", 2),
-("Publicação do Usuário 3", "Essa é a publicação do usuário 3! Oba!", 3);
\ No newline at end of file
+("Publicação do Usuário 1", "Essa é a publicação do usuário 1! Oba! #primeiro #olá", 1),
+("Publicação do Usuário 2", "Essa é a publicação do usuário 2! Oba! #olá", 2),
+("Publicação do Usuário 3", "Essa é a publicação do usuário 3! Oba!", 3);
+
+insert into posts(title, content, author_id, in_reply_to_id)
+values
+("", "Respondendo a publicação do usuário 1!", 2, 1),
+("", "Obrigado, usuário 2!", 1, 4);
+insert into comments(post_id, author_id, content)
+values
+(1, 2, "Muito bom, usuário 1!"),
+(1, 3, "Concordo!"),
+(3, 1, "Boa publicação, usuário 3!");
+
+insert into post_likes(user_id, post_id)
+values
+(2, 1),
+(3, 1),
+(1, 3);
+
+update posts p set p.likes = (select count(*) from post_likes l where l.post_id = p.id);
+
+insert into post_reactions(post_id, user_id, kind)
+values
+(1, 2, "heart"),
+(1, 3, "heart"),
+(1, 3, "celebrate"),
+(2, 1, "laugh");
+
+insert into posts(title, content, author_id, quoted_post_id)
+values
+("Vejam isso", "Olhem a publicação do @usuario_2!", 3, 2);
+
+insert into reposts(user_id, post_id)
+values
+(1, 2),
+(3, 1);
+
+insert into bookmark_collections(user_id, name)
+values
+(1, "Ler depois");
+
+insert into bookmarks(user_id, post_id, collection_id)
+values
+(1, 2, 1),
+(1, 6, null),
+(2, 3, null);
+
+insert into tags(name)
+values
+("primeiro"),
+("olá");
+
+insert into post_tags(post_id, tag_id, position)
+values
+(1, 1, 1),
+(1, 2, 2),
+(2, 2, 1);
+
+insert into post_mentions(post_id, field, start_offset, end_offset, nick, user_id)
+values
+(6, "content", 22, 32, "usuario_2", 2);
+
+insert into notifications(user_id, kind, actor_id, post_id)
+values
+(2, "mention", 3, 6);
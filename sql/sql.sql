@@ -1,16 +1,49 @@
 CREATE DATABASE IF NOT EXISTS social_network;
 USE social_network;
 
+DROP TABLE IF EXISTS lockout_audits;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oidc_states;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS email_verifications;
+DROP TABLE IF EXISTS magic_links;
+DROP TABLE IF EXISTS password_resets;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS rotated_refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS oauth_clients;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS post_mentions;
+DROP TABLE IF EXISTS trending_tags;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS bookmarks;
+DROP TABLE IF EXISTS bookmark_collections;
+DROP TABLE IF EXISTS reposts;
+DROP TABLE IF EXISTS post_reactions;
+DROP TABLE IF EXISTS post_likes;
+DROP TABLE IF EXISTS comments;
 DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS user_blocks;
 DROP TABLE IF EXISTS followers;
 DROP TABLE IF EXISTS users;
 
+-- nick and e-mail are compared ignoring case, so "Foo" and "foo" can't be
+-- two different accounts.
 CREATE TABLE users(
     id int auto_increment primary key,
     name varchar(50) not null,
-    nick varchar(50) not null unique,
-    email varchar(255) not null unique,
+    nick varchar(50) character set utf8mb4 collate utf8mb4_0900_as_ci not null unique,
+    email varchar(255) character set utf8mb4 collate utf8mb4_0900_as_ci not null unique,
+    email_verified_at datetime null default null,
+    pending_email varchar(255) character set utf8mb4 collate utf8mb4_0900_as_ci null default null,
     password varchar(255) not null,
+    role varchar(20) not null default 'user',
+    totp_secret varchar(64) null default null,
+    totp_enabled_at datetime null default null,
+    totp_last_step bigint not null default 0,
     created_at timestamp default current_timestamp()
 ) ENGINE=INNODB;
 
@@ -29,6 +62,22 @@ CREATE TABLE followers (
     primary key(user_id, follower_id)
 ) ENGINE=INNODB;
 
+CREATE TABLE user_blocks(
+    blocker_id int not null,
+    FOREIGN KEY (blocker_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    blocked_id int not null,
+    FOREIGN KEY (blocked_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp,
+
+    primary key(blocker_id, blocked_id)
+) ENGINE=INNODB;
+
 CREATE TABLE posts(
     id int auto_increment primary key,
     title varchar(50) not null,
@@ -39,6 +88,405 @@ CREATE TABLE posts(
     REFERENCES users(id)
     ON DELETE CASCADE,
 
+    -- Replies outlive the post they answer, becoming the start of their
+    -- own thread.
+    in_reply_to_id int null default null,
+    FOREIGN KEY (in_reply_to_id)
+    REFERENCES posts(id)
+    ON DELETE SET NULL,
+
+    -- No foreign key, so a quote post still tells it quoted something after
+    -- the original is deleted.
+    quoted_post_id int null default null,
+
     likes int default 0,
+    created_at timestamp default current_timestamp,
+
+    index (in_reply_to_id, id),
+    index (quoted_post_id)
+) ENGINE=INNODB;
+
+CREATE TABLE comments(
+    id int auto_increment primary key,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    author_id int not null,
+    FOREIGN KEY (author_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    content varchar(300) not null,
+    created_at timestamp default current_timestamp,
+    updated_at datetime null default null,
+
+    index (post_id, id)
+) ENGINE=INNODB;
+
+CREATE TABLE post_likes(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp,
+
+    primary key(user_id, post_id),
+    index (post_id, created_at)
+) ENGINE=INNODB;
+
+CREATE TABLE post_reactions(
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    kind varchar(32) not null,
+    created_at timestamp default current_timestamp,
+
+    primary key(post_id, user_id, kind),
+    index (post_id, kind, created_at)
+) ENGINE=INNODB;
+
+CREATE TABLE reposts(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp,
+
+    primary key(user_id, post_id),
+    index (post_id)
+) ENGINE=INNODB;
+
+CREATE TABLE bookmark_collections(
+    id int auto_increment primary key,
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    name varchar(100) not null,
+    created_at timestamp default current_timestamp,
+
+    unique (user_id, name)
+) ENGINE=INNODB;
+
+CREATE TABLE bookmarks(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    collection_id int null default null,
+    FOREIGN KEY (collection_id)
+    REFERENCES bookmark_collections(id)
+    ON DELETE SET NULL,
+
+    created_at timestamp default current_timestamp,
+
+    primary key(user_id, post_id),
+    index (user_id, created_at)
+) ENGINE=INNODB;
+
+CREATE TABLE tags(
+    id int auto_increment primary key,
+    name varchar(100) character set utf8mb4 collate utf8mb4_0900_as_cs not null unique,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE post_tags(
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    tag_id int not null,
+    FOREIGN KEY (tag_id)
+    REFERENCES tags(id)
+    ON DELETE CASCADE,
+
+    position int not null,
+
+    primary key(post_id, tag_id),
+    index (tag_id, post_id)
+) ENGINE=INNODB;
+
+CREATE TABLE trending_tags(
+    window_seconds int not null,
+
+    tag_id int not null,
+    FOREIGN KEY (tag_id)
+    REFERENCES tags(id)
+    ON DELETE CASCADE,
+
+    score double not null,
+    posts_count int not null,
+    computed_at timestamp default current_timestamp,
+
+    primary key(window_seconds, tag_id),
+    index (window_seconds, score)
+) ENGINE=INNODB;
+
+CREATE TABLE post_mentions(
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    field varchar(10) not null,
+    start_offset int not null,
+    end_offset int not null,
+    nick varchar(50) not null,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    primary key(post_id, field, start_offset),
+    index (user_id, post_id)
+) ENGINE=INNODB;
+
+CREATE TABLE notifications(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    kind varchar(20) not null,
+
+    actor_id int not null,
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int null default null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    read_at timestamp null default null,
+    created_at timestamp default current_timestamp,
+
+    unique (user_id, kind, post_id),
+    index (user_id, created_at)
+) ENGINE=INNODB;
+
+CREATE TABLE oauth_clients(
+    id int auto_increment primary key,
+    client_id varchar(64) not null unique,
+    client_secret_hash char(64) null default null,
+    name varchar(100) not null,
+    redirect_uris text not null,
+    scopes varchar(255) not null,
+
+    owner_id int not null,
+    FOREIGN KEY (owner_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE sessions(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    refresh_token_hash char(64) not null,
+    user_agent varchar(255) not null default '',
+    ip_address varchar(45) not null default '',
+    scopes varchar(255) not null default '',
+
+    client_id varchar(64) null default null,
+    FOREIGN KEY (client_id)
+    REFERENCES oauth_clients(client_id)
+    ON DELETE CASCADE,
+
+    expires_at datetime not null,
+    revoked_at datetime null default null,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE rotated_refresh_tokens(
+    refresh_token_hash char(64) primary key,
+
+    session_id int not null,
+    FOREIGN KEY (session_id)
+    REFERENCES sessions(id)
+    ON DELETE CASCADE,
+
+    rotated_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE recovery_codes(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    code_hash char(64) not null,
+    used_at datetime null default null,
+    created_at timestamp default current_timestamp,
+
+    unique(user_id, code_hash)
+) ENGINE=INNODB;
+
+CREATE TABLE password_resets(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    used_at datetime null default null,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE email_verifications(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    email varchar(255) not null,
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    used_at datetime null default null,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE magic_links(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    email varchar(255) not null,
+    link_id_hash char(64) not null unique,
+    expires_at datetime not null,
+    used_at datetime null default null,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE login_attempts(
+    attempt_key varchar(255) primary key,
+    failures int not null default 0,
+    last_failure_at datetime not null,
+    locked_until datetime not null
+) ENGINE=INNODB;
+
+CREATE TABLE lockout_audits(
+    id int auto_increment primary key,
+    attempt_key varchar(255) not null,
+    ip_address varchar(45) not null,
+    failures int not null,
+    locked_until datetime not null,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE personal_access_tokens(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    name varchar(100) not null,
+    token_hash char(64) not null unique,
+    scopes varchar(255) not null,
+    expires_at datetime null default null,
+    last_used_at datetime null default null,
+    revoked_at datetime null default null,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE user_identities(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    provider varchar(50) not null,
+    subject varchar(255) not null,
+    email varchar(255) not null,
+    created_at timestamp default current_timestamp,
+
+    unique(provider, subject)
+) ENGINE=INNODB;
+
+CREATE TABLE oidc_states(
+    id int auto_increment primary key,
+    state_hash char(64) not null unique,
+    provider varchar(50) not null,
+    code_verifier varchar(64) not null,
+    nonce varchar(64) not null,
+    expires_at datetime not null,
+    created_at timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE oauth_authorization_codes(
+    id int auto_increment primary key,
+    code_hash char(64) not null unique,
+
+    client_id varchar(64) not null,
+    FOREIGN KEY (client_id)
+    REFERENCES oauth_clients(client_id)
+    ON DELETE CASCADE,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    redirect_uri text not null,
+    scopes varchar(255) not null,
+    code_challenge varchar(128) not null default '',
+    code_challenge_method varchar(10) not null default '',
+    session_id int null default null,
+    expires_at datetime not null,
+    used_at datetime null default null,
     created_at timestamp default current_timestamp
 ) ENGINE=INNODB;
\ No newline at end of file
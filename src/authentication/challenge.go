@@ -0,0 +1,45 @@
+package authentication
+
+import (
+	"errors"
+	"social-network/src/config"
+	"social-network/src/security"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const challengePurpose = "2fa"
+
+var ErrInvalidChallengeToken = errors.New("invalid challenge token")
+
+// GenerateChallengeToken identifies each challenge, so failed attempts can
+// be counted against it.
+func GenerateChallengeToken(userID uint64) (string, error) {
+	challengeID, error := security.RandomToken(16)
+	if error != nil {
+		return "", error
+	}
+
+	permissions := jwt.MapClaims{}
+	permissions["purpose"] = challengePurpose
+	permissions["exp"] = time.Now().Add(config.ChallengeTokenDuration).Unix()
+	permissions["userId"] = userID
+	permissions["jti"] = challengeID
+
+	return signToken(permissions)
+}
+
+// ValidateChallengeToken returns the user and the ID of the challenge.
+func ValidateChallengeToken(tokenStr string) (uint64, string, error) {
+	var claims accessTokenClaims
+	token, error := jwt.ParseWithClaims(tokenStr, &claims, getVerificationKey)
+	if error != nil {
+		return 0, "", error
+	}
+
+	if !token.Valid || claims.Purpose != challengePurpose || claims.UserID == 0 || claims.Id == "" {
+		return 0, "", ErrInvalidChallengeToken
+	}
+	return claims.UserID, claims.Id, nil
+}
@@ -0,0 +1,74 @@
+package authentication
+
+import (
+	"social-network/src/config"
+	"testing"
+	"time"
+)
+
+func useTestSecret(t *testing.T) {
+	t.Helper()
+	secretKey := config.SecretKey
+	config.SecretKey = []byte("test secret")
+	t.Cleanup(func() { config.SecretKey = secretKey })
+}
+
+func TestChallengeToken(t *testing.T) {
+	useTestSecret(t)
+
+	token, error := GenerateChallengeToken(7)
+	if error != nil {
+		t.Fatal(error)
+	}
+	other, error := GenerateChallengeToken(7)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	userID, challengeID, error := ValidateChallengeToken(token)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if userID != 7 || challengeID == "" {
+		t.Errorf("userID = %d, challengeID = %q", userID, challengeID)
+	}
+
+	if _, otherID, _ := ValidateChallengeToken(other); otherID == challengeID {
+		t.Error("challenges share the same ID")
+	}
+}
+
+func TestChallengeTokenRejectsOtherTokens(t *testing.T) {
+	useTestSecret(t)
+
+	accessToken, error := GenerateToken(7, 3, AllScopes, "user")
+	if error != nil {
+		t.Fatal(error)
+	}
+	if _, _, error := ValidateChallengeToken(accessToken); error != ErrInvalidChallengeToken {
+		t.Errorf("access token accepted as challenge: %v", error)
+	}
+
+	challengeToken, error := GenerateChallengeToken(7)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if _, error := ParseAccessToken(challengeToken); error == nil {
+		t.Error("challenge token accepted as access token")
+	}
+}
+
+func TestChallengeTokenExpires(t *testing.T) {
+	useTestSecret(t)
+	duration := config.ChallengeTokenDuration
+	config.ChallengeTokenDuration = -time.Minute
+	t.Cleanup(func() { config.ChallengeTokenDuration = duration })
+
+	token, error := GenerateChallengeToken(7)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if _, _, error := ValidateChallengeToken(token); error == nil {
+		t.Error("expired challenge accepted")
+	}
+}
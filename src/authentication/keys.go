@@ -0,0 +1,202 @@
+package authentication
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"social-network/src/config"
+	"social-network/src/models"
+	"sort"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+type key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  interface{}
+}
+
+var (
+	signingKey       *key
+	verificationKeys = map[string]*key{}
+)
+
+// LoadKeys reads the signing key and the extra verification keys from the
+// files set in the configuration. Rotating keys is done by publishing the new
+// public key as a verification key first, then switching the signing key to it
+// while the old public key stays in the verification list until the last
+// tokens signed by it expire. Without a signing key tokens are signed and
+// verified with SECRET_KEY using HS256. Once there is one, HS256 tokens are
+// only accepted with JWT_ACCEPT_HS256 while migrating, so verifiers don't
+// need the shared secret.
+func LoadKeys() error {
+	signingKey = nil
+	verificationKeys = map[string]*key{}
+
+	if config.JWTSigningKeyFile != "" {
+		privateKey, error := loadPrivateKey(config.JWTSigningKeyFile)
+		if error != nil {
+			return error
+		}
+		signingKey = privateKey
+		verificationKeys[privateKey.ID] = privateKey
+	}
+
+	for _, file := range config.JWTVerificationKeyFiles {
+		publicKey, error := loadPublicKey(file)
+		if error != nil {
+			return error
+		}
+		verificationKeys[publicKey.ID] = publicKey
+	}
+
+	if signingKey == nil && len(config.SecretKey) == 0 {
+		return errors.New("no key configured to sign tokens")
+	}
+	return nil
+}
+
+func signToken(permissions jwt.MapClaims) (string, error) {
+	if signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, permissions).SignedString(config.SecretKey)
+	}
+
+	token := jwt.NewWithClaims(signingKey.Method, permissions)
+	token.Header["kid"] = signingKey.ID
+	return token.SignedString(signingKey.PrivateKey)
+}
+
+func getVerificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(config.SecretKey) == 0 || (signingKey != nil && !config.JWTAcceptHS256) {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return config.SecretKey, nil
+	}
+
+	keyID, _ := token.Header["kid"].(string)
+	verificationKey, ok := verificationKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", keyID)
+	}
+
+	if verificationKey.Method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+	return verificationKey.PublicKey, nil
+}
+
+func JWKS() models.JWKS {
+	jwks := models.JWKS{Keys: []models.JWK{}}
+	for _, verificationKey := range verificationKeys {
+		jwk := models.JWK{
+			Use:       "sig",
+			Algorithm: verificationKey.Method.Alg(),
+			KeyID:     verificationKey.ID,
+		}
+
+		switch publicKey := verificationKey.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.Modulus = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+	return jwks
+}
+
+func loadPrivateKey(file string) (*key, error) {
+	block, error := readPEM(file)
+	if error != nil {
+		return nil, error
+	}
+
+	privateKey, error := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if error != nil {
+		if privateKey, error = x509.ParsePKCS1PrivateKey(block.Bytes); error != nil {
+			return nil, fmt.Errorf("%s: unsupported private key", file)
+		}
+	}
+
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return newKey(privateKey, &privateKey.PublicKey)
+	case ed25519.PrivateKey:
+		return newKey(privateKey, privateKey.Public())
+	default:
+		return nil, fmt.Errorf("%s: unsupported private key", file)
+	}
+}
+
+func loadPublicKey(file string) (*key, error) {
+	block, error := readPEM(file)
+	if error != nil {
+		return nil, error
+	}
+
+	publicKey, error := x509.ParsePKIXPublicKey(block.Bytes)
+	if error != nil {
+		if publicKey, error = x509.ParsePKCS1PublicKey(block.Bytes); error != nil {
+			return nil, fmt.Errorf("%s: unsupported public key", file)
+		}
+	}
+	return newKey(nil, publicKey)
+}
+
+func newKey(privateKey, publicKey interface{}) (*key, error) {
+	var method jwt.SigningMethod
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = SigningMethodEdDSA
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	publicKeyBytes, error := x509.MarshalPKIXPublicKey(publicKey)
+	if error != nil {
+		return nil, error
+	}
+	thumbprint := sha256.Sum256(publicKeyBytes)
+
+	return &key{
+		ID:         hex.EncodeToString(thumbprint[:8]),
+		Method:     method,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+	}, nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	content, error := ioutil.ReadFile(file)
+	if error != nil {
+		return nil, error
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+	return block, nil
+}
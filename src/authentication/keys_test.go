@@ -0,0 +1,287 @@
+package authentication
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"social-network/src/config"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// useKeys loads the keys from the files, restoring the previous keys when
+// the test ends.
+func useKeys(t *testing.T, signingKeyFile string, verificationKeyFiles ...string) error {
+	t.Helper()
+	signing, verification := signingKey, verificationKeys
+	signingKeyFile, config.JWTSigningKeyFile = config.JWTSigningKeyFile, signingKeyFile
+	verificationKeyFiles, config.JWTVerificationKeyFiles = config.JWTVerificationKeyFiles, verificationKeyFiles
+	acceptHS256 := config.JWTAcceptHS256
+	t.Cleanup(func() {
+		signingKey, verificationKeys = signing, verification
+		config.JWTSigningKeyFile, config.JWTVerificationKeyFiles = signingKeyFile, verificationKeyFiles
+		config.JWTAcceptHS256 = acceptHS256
+	})
+	return LoadKeys()
+}
+
+func writePEM(t *testing.T, blockType string, bytes []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "key.pem")
+	if error := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}), 0600); error != nil {
+		t.Fatal(error)
+	}
+	return file
+}
+
+func writePrivateKey(t *testing.T, privateKey interface{}) string {
+	t.Helper()
+	bytes, error := x509.MarshalPKCS8PrivateKey(privateKey)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return writePEM(t, "PRIVATE KEY", bytes)
+}
+
+func writePublicKey(t *testing.T, publicKey interface{}) string {
+	t.Helper()
+	bytes, error := x509.MarshalPKIXPublicKey(publicKey)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return writePEM(t, "PUBLIC KEY", bytes)
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	privateKey, error := rsa.GenerateKey(rand.Reader, 2048)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return privateKey
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, privateKey, error := ed25519.GenerateKey(rand.Reader)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return privateKey
+}
+
+// newECDSAKey makes a key of a type tokens can't be signed with.
+func newECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	privateKey, error := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return privateKey
+}
+
+func generateAccessToken(t *testing.T) string {
+	t.Helper()
+	token, error := GenerateToken(7, 3, AllScopes, "user")
+	if error != nil {
+		t.Fatal(error)
+	}
+	return token
+}
+
+func tokenHeader(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	parsed, _, error := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if error != nil {
+		t.Fatal(error)
+	}
+	return parsed.Header
+}
+
+func TestSignWithKeys(t *testing.T) {
+	tests := map[string]interface{}{
+		"RS256": newRSAKey(t),
+		"EdDSA": newEd25519Key(t),
+	}
+
+	for algorithm, privateKey := range tests {
+		t.Run(algorithm, func(t *testing.T) {
+			if error := useKeys(t, writePrivateKey(t, privateKey)); error != nil {
+				t.Fatal(error)
+			}
+
+			token := generateAccessToken(t)
+			if header := tokenHeader(t, token); header["alg"] != algorithm || header["kid"] != signingKey.ID {
+				t.Errorf("header = %v, want %s signed by %s", header, algorithm, signingKey.ID)
+			}
+
+			accessToken, error := ParseAccessToken(token)
+			if error != nil {
+				t.Fatal(error)
+			}
+			if accessToken.UserID != 7 || accessToken.SessionID != 3 {
+				t.Errorf("unexpected access token %+v", accessToken)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey := newRSAKey(t), newEd25519Key(t)
+	if error := useKeys(t, writePrivateKey(t, oldKey)); error != nil {
+		t.Fatal(error)
+	}
+	oldToken := generateAccessToken(t)
+
+	// The new key signs, the old one is kept to verify what it signed.
+	if error := useKeys(t, writePrivateKey(t, newKey), writePublicKey(t, &oldKey.PublicKey)); error != nil {
+		t.Fatal(error)
+	}
+	if _, error := ParseAccessToken(oldToken); error != nil {
+		t.Errorf("token of the previous key rejected: %v", error)
+	}
+	if _, error := ParseAccessToken(generateAccessToken(t)); error != nil {
+		t.Errorf("token of the new key rejected: %v", error)
+	}
+
+	// Once the old key is dropped its tokens stop working.
+	if error := useKeys(t, writePrivateKey(t, newKey)); error != nil {
+		t.Fatal(error)
+	}
+	if _, error := ParseAccessToken(oldToken); error == nil {
+		t.Error("token of a dropped key accepted")
+	}
+}
+
+func TestHS256OnlyAcceptedWhileMigrating(t *testing.T) {
+	useTestSecret(t)
+	if error := useKeys(t, ""); error != nil {
+		t.Fatal(error)
+	}
+	hs256Token := generateAccessToken(t)
+	if _, error := ParseAccessToken(hs256Token); error != nil {
+		t.Fatalf("HS256 token rejected without keys: %v", error)
+	}
+
+	if error := useKeys(t, writePrivateKey(t, newEd25519Key(t))); error != nil {
+		t.Fatal(error)
+	}
+	if _, error := ParseAccessToken(hs256Token); error == nil {
+		t.Error("HS256 token accepted once keys are configured")
+	}
+
+	config.JWTAcceptHS256 = true
+	if _, error := ParseAccessToken(hs256Token); error != nil {
+		t.Errorf("HS256 token rejected while migrating: %v", error)
+	}
+}
+
+func TestLoadKeysErrors(t *testing.T) {
+	ecdsaKey := newECDSAKey(t)
+
+	tests := map[string]func(t *testing.T) error{
+		"no key": func(t *testing.T) error {
+			secretKey := config.SecretKey
+			config.SecretKey = nil
+			t.Cleanup(func() { config.SecretKey = secretKey })
+			return useKeys(t, "")
+		},
+		"missing file": func(t *testing.T) error {
+			return useKeys(t, filepath.Join(t.TempDir(), "missing.pem"))
+		},
+		"not PEM": func(t *testing.T) error {
+			file := filepath.Join(t.TempDir(), "key.pem")
+			ioutil.WriteFile(file, []byte("not a key"), 0600)
+			return useKeys(t, file)
+		},
+		"unsupported private key": func(t *testing.T) error {
+			return useKeys(t, writePrivateKey(t, ecdsaKey))
+		},
+		"unsupported public key": func(t *testing.T) error {
+			return useKeys(t, writePrivateKey(t, newRSAKey(t)), writePublicKey(t, &ecdsaKey.PublicKey))
+		},
+	}
+
+	for name, load := range tests {
+		t.Run(name, func(t *testing.T) {
+			useTestSecret(t)
+			if error := load(t); error == nil {
+				t.Fatal("keys loaded")
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, ed25519Key := newRSAKey(t), newEd25519Key(t)
+	if error := useKeys(t, writePrivateKey(t, rsaKey), writePublicKey(t, ed25519Key.Public())); error != nil {
+		t.Fatal(error)
+	}
+
+	jwks := JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("keys = %+v, want 2", jwks.Keys)
+	}
+	if jwks.Keys[0].KeyID > jwks.Keys[1].KeyID {
+		t.Error("keys aren't sorted by ID")
+	}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "sig" || verificationKeys[jwk.KeyID] == nil {
+			t.Errorf("unexpected key %+v", jwk)
+		}
+
+		switch jwk.KeyType {
+		case "RSA":
+			modulus, _ := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+			exponent, _ := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+			if jwk.Algorithm != "RS256" || new(big.Int).SetBytes(modulus).Cmp(rsaKey.N) != 0 || new(big.Int).SetBytes(exponent).Int64() != int64(rsaKey.E) {
+				t.Errorf("RSA key doesn't match: %+v", jwk)
+			}
+		case "OKP":
+			x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+			if jwk.Algorithm != "EdDSA" || jwk.Curve != "Ed25519" || !ed25519.PublicKey(x).Equal(ed25519Key.Public()) {
+				t.Errorf("Ed25519 key doesn't match: %+v", jwk)
+			}
+		default:
+			t.Errorf("unexpected key type %q", jwk.KeyType)
+		}
+	}
+}
+
+func TestSigningMethodEdDSA(t *testing.T) {
+	privateKey := newEd25519Key(t)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	signature, error := SigningMethodEdDSA.Sign("header.payload", privateKey)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if error := SigningMethodEdDSA.Verify("header.payload", signature, publicKey); error != nil {
+		t.Errorf("signature rejected: %v", error)
+	}
+
+	if error := SigningMethodEdDSA.Verify("header.tampered", signature, publicKey); error == nil {
+		t.Error("signature of other content accepted")
+	}
+	otherKey := newEd25519Key(t).Public()
+	if error := SigningMethodEdDSA.Verify("header.payload", signature, otherKey); error == nil {
+		t.Error("signature accepted by another key")
+	}
+
+	if _, error := SigningMethodEdDSA.Sign("header.payload", newRSAKey(t)); error != jwt.ErrInvalidKeyType {
+		t.Errorf("signing with an RSA key error = %v, want %v", error, jwt.ErrInvalidKeyType)
+	}
+	if error := SigningMethodEdDSA.Verify("header.payload", signature, privateKey); error != jwt.ErrInvalidKeyType {
+		t.Errorf("verifying with a private key error = %v, want %v", error, jwt.ErrInvalidKeyType)
+	}
+}
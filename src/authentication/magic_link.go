@@ -0,0 +1,44 @@
+package authentication
+
+import (
+	"errors"
+	"social-network/src/config"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const magicLinkPurpose = "magic-link"
+
+var ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+
+// GenerateMagicLinkToken signs the e-mail the link was requested for together
+// with the link identifier, whose single use is tracked by the caller.
+func GenerateMagicLinkToken(email, linkID string) (string, error) {
+	permissions := jwt.MapClaims{}
+	permissions["purpose"] = magicLinkPurpose
+	permissions["exp"] = time.Now().Add(config.MagicLinkDuration).Unix()
+	permissions["email"] = email
+	permissions["jti"] = linkID
+
+	return signToken(permissions)
+}
+
+func ValidateMagicLinkToken(tokenStr string) (string, string, error) {
+	token, error := jwt.Parse(tokenStr, getVerificationKey)
+	if error != nil {
+		return "", "", ErrInvalidMagicLink
+	}
+
+	permissions, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || permissions["purpose"] != magicLinkPurpose {
+		return "", "", ErrInvalidMagicLink
+	}
+
+	email, _ := permissions["email"].(string)
+	linkID, _ := permissions["jti"].(string)
+	if email == "" || linkID == "" {
+		return "", "", ErrInvalidMagicLink
+	}
+	return email, linkID, nil
+}
@@ -0,0 +1,63 @@
+package authentication
+
+import (
+	"social-network/src/config"
+	"testing"
+	"time"
+)
+
+func TestMagicLinkToken(t *testing.T) {
+	useTestSecret(t)
+
+	token, error := GenerateMagicLinkToken("jane@example.com", "link")
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	email, linkID, error := ValidateMagicLinkToken(token)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if email != "jane@example.com" || linkID != "link" {
+		t.Errorf("email = %q, linkID = %q", email, linkID)
+	}
+
+	if _, error := ParseAccessToken(token); error == nil {
+		t.Error("magic link token accepted as an access token")
+	}
+}
+
+func TestMagicLinkTokenRejectsOtherTokens(t *testing.T) {
+	useTestSecret(t)
+
+	accessToken, error := GenerateToken(7, 3, AllScopes, "user")
+	if error != nil {
+		t.Fatal(error)
+	}
+	challengeToken, error := GenerateChallengeToken(7)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	for _, token := range []string{accessToken, challengeToken, "", "not a token"} {
+		if _, _, error := ValidateMagicLinkToken(token); error != ErrInvalidMagicLink {
+			t.Errorf("error = %v, want %v", error, ErrInvalidMagicLink)
+		}
+	}
+}
+
+func TestMagicLinkTokenExpires(t *testing.T) {
+	useTestSecret(t)
+
+	duration := config.MagicLinkDuration
+	config.MagicLinkDuration = -time.Minute
+	t.Cleanup(func() { config.MagicLinkDuration = duration })
+
+	token, error := GenerateMagicLinkToken("jane@example.com", "link")
+	if error != nil {
+		t.Fatal(error)
+	}
+	if _, _, error := ValidateMagicLinkToken(token); error != ErrInvalidMagicLink {
+		t.Fatalf("error = %v, want %v", error, ErrInvalidMagicLink)
+	}
+}
@@ -0,0 +1,24 @@
+package authentication
+
+import (
+	"net/http"
+	"social-network/src/security"
+	"strings"
+)
+
+const PersonalAccessTokenPrefix = "snpat_"
+
+func GeneratePersonalAccessToken() (string, error) {
+	secret, error := security.RandomToken(32)
+	if error != nil {
+		return "", error
+	}
+	return PersonalAccessTokenPrefix + secret, nil
+}
+
+// GetPersonalAccessToken returns the bearer token of the request when it is a
+// personal access token instead of a JWT.
+func GetPersonalAccessToken(r *http.Request) (string, bool) {
+	token := getToken(r)
+	return token, strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
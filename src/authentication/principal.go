@@ -0,0 +1,77 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social-network/src/models"
+)
+
+var ErrUnauthenticated = errors.New("request not authenticated")
+
+type principalKey struct{}
+
+// Principal is who the authentication middleware resolved the request to,
+// either from a JWT bound to a session or from a personal access token.
+type Principal struct {
+	UserID                uint64
+	SessionID             uint64
+	PersonalAccessTokenID uint64
+	Scopes                []string
+	Role                  string
+}
+
+func (principal Principal) HasScope(scope string) bool {
+	return HasScope(principal.Scopes, scope)
+}
+
+func (principal Principal) HasRole(role string) bool {
+	return models.HasRole(principal.Role, role)
+}
+
+func (principal Principal) IsPersonalAccessToken() bool {
+	return principal.PersonalAccessTokenID != 0
+}
+
+func WithPrincipal(r *http.Request, principal Principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+}
+
+// GetPrincipal returns the principal recorded by the authentication
+// middleware, failing on routes that don't require authentication.
+func GetPrincipal(r *http.Request) (Principal, error) {
+	principal, ok := r.Context().Value(principalKey{}).(Principal)
+	if !ok {
+		return Principal{}, ErrUnauthenticated
+	}
+	return principal, nil
+}
+
+func GetUserID(r *http.Request) (uint64, error) {
+	principal, error := GetPrincipal(r)
+	return principal.UserID, error
+}
+
+// GetSessionID returns zero for personal access tokens, which aren't bound
+// to a login session.
+func GetSessionID(r *http.Request) (uint64, error) {
+	principal, error := GetPrincipal(r)
+	return principal.SessionID, error
+}
+
+func IsPersonalAccessToken(r *http.Request) bool {
+	principal, error := GetPrincipal(r)
+	return error == nil && principal.IsPersonalAccessToken()
+}
+
+// IsAdmin and IsModerator tell whether the authenticated user holds the role,
+// or a higher one.
+func IsAdmin(r *http.Request) bool {
+	principal, error := GetPrincipal(r)
+	return error == nil && principal.HasRole(models.RoleAdmin)
+}
+
+func IsModerator(r *http.Request) bool {
+	principal, error := GetPrincipal(r)
+	return error == nil && principal.HasRole(models.RoleModerator)
+}
@@ -0,0 +1,31 @@
+package authentication
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrincipal(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, error := GetPrincipal(r); error != ErrUnauthenticated {
+		t.Fatalf("error = %v, want %v", error, ErrUnauthenticated)
+	}
+	if IsPersonalAccessToken(r) || IsModerator(r) || IsAdmin(r) {
+		t.Error("unauthenticated request granted something")
+	}
+
+	r = WithPrincipal(r, Principal{UserID: 7, SessionID: 3, Scopes: []string{ScopePostsRead}, Role: "moderator"})
+	if userID, error := GetUserID(r); error != nil || userID != 7 {
+		t.Errorf("user = %d, error = %v", userID, error)
+	}
+	if sessionID, error := GetSessionID(r); error != nil || sessionID != 3 {
+		t.Errorf("session = %d, error = %v", sessionID, error)
+	}
+	if IsPersonalAccessToken(r) {
+		t.Error("session taken as a personal access token")
+	}
+	if !IsModerator(r) || IsAdmin(r) {
+		t.Error("moderator role not resolved")
+	}
+}
@@ -0,0 +1,32 @@
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"social-network/src/security"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+func GenerateRefreshSecret() (string, error) {
+	return security.RandomToken(32)
+}
+
+func FormatRefreshToken(sessionID uint64, secret string) string {
+	return fmt.Sprintf("%d.%s", sessionID, secret)
+}
+
+func ParseRefreshToken(token string) (uint64, string, error) {
+	tokenSplit := strings.SplitN(token, ".", 2)
+	if len(tokenSplit) != 2 || tokenSplit[1] == "" {
+		return 0, "", ErrInvalidRefreshToken
+	}
+
+	sessionID, error := strconv.ParseUint(tokenSplit[0], 10, 64)
+	if error != nil {
+		return 0, "", ErrInvalidRefreshToken
+	}
+	return sessionID, tokenSplit[1], nil
+}
@@ -0,0 +1,37 @@
+package authentication
+
+import "fmt"
+
+const (
+	ScopePostsRead    = "posts:read"
+	ScopePostsWrite   = "posts:write"
+	ScopeUsersRead    = "users:read"
+	ScopeUsersWrite   = "users:write"
+	ScopeFollowsWrite = "follows:write"
+)
+
+var AllScopes = []string{
+	ScopePostsRead,
+	ScopePostsWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeFollowsWrite,
+}
+
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !HasScope(AllScopes, scope) {
+			return fmt.Errorf("unknown scope %s", scope)
+		}
+	}
+	return nil
+}
+
+func HasScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
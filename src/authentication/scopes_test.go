@@ -0,0 +1,35 @@
+package authentication
+
+import "testing"
+
+func TestValidateScopes(t *testing.T) {
+	if error := ValidateScopes(AllScopes); error != nil {
+		t.Errorf("known scopes rejected: %v", error)
+	}
+	for _, scopes := range [][]string{{"posts:delete"}, {ScopePostsRead, "admin"}, {""}} {
+		if error := ValidateScopes(scopes); error == nil {
+			t.Errorf("scopes %q accepted", scopes)
+		}
+	}
+}
+
+func TestAccessTokenCarriesScopes(t *testing.T) {
+	useTestSecret(t)
+
+	token, error := GenerateToken(7, 3, []string{ScopePostsRead}, "user")
+	if error != nil {
+		t.Fatal(error)
+	}
+	accessToken, error := ParseAccessToken(token)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	principal := Principal{UserID: accessToken.UserID, Scopes: accessToken.Scopes}
+	if !principal.HasScope(ScopePostsRead) {
+		t.Error("token lost its scope")
+	}
+	if principal.HasScope(ScopePostsWrite) {
+		t.Error("read-only token can write")
+	}
+}
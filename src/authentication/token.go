@@ -2,40 +2,39 @@ package authentication
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"social-network/src/config"
-	"strconv"
 	"strings"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-func GenerateToken(userID uint64) (string, error) {
-	permissions := jwt.MapClaims{}
-	permissions["authorized"] = true
-	permissions["exp"] = time.Now().Add(time.Hour * 6).Unix()
-	permissions["userId"] = userID
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
+var errInvalidToken = errors.New("invalid token")
 
-	return token.SignedString(config.SecretKey)
+// accessTokenClaims are the claims of the JWTs issued by GenerateToken.
+// Challenge and magic link tokens set a purpose, so they can't be used as
+// access tokens.
+type accessTokenClaims struct {
+	Authorized bool     `json:"authorized"`
+	UserID     uint64   `json:"userId"`
+	SessionID  uint64   `json:"sessionId"`
+	Scopes     []string `json:"scopes"`
+	Role       string   `json:"role"`
+	Purpose    string   `json:"purpose,omitempty"`
+	jwt.StandardClaims
 }
 
-func ValidateToken(r *http.Request) error {
-	tokenStr := getToken(r)
-
-	token, error := jwt.Parse(tokenStr, getSecretKey)
-	if error != nil {
-		return error
-	}
-
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
-	}
+func GenerateToken(userID, sessionID uint64, scopes []string, role string) (string, error) {
+	permissions := jwt.MapClaims{}
+	permissions["authorized"] = true
+	permissions["exp"] = time.Now().Add(config.AccessTokenDuration).Unix()
+	permissions["userId"] = userID
+	permissions["sessionId"] = sessionID
+	permissions["scopes"] = scopes
+	permissions["role"] = role
 
-	return errors.New("invalid token")
+	return signToken(permissions)
 }
 
 func getToken(r *http.Request) string {
@@ -47,26 +46,30 @@ func getToken(r *http.Request) string {
 	return ""
 }
 
-func getSecretKey(token *jwt.Token) (interface{}, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("unexpected subscription method %v", token.Header["alg"])
-	}
-	return config.SecretKey, nil
+type AccessToken struct {
+	UserID    uint64
+	SessionID uint64
+	Scopes    []string
+	Role      string
+	ExpiresAt time.Time
 }
 
-func GetUserID(r *http.Request) (uint64, error) {
-	tokenStr := getToken(r)
-	token, error := jwt.Parse(tokenStr, getSecretKey)
+func ParseAccessToken(tokenStr string) (AccessToken, error) {
+	var claims accessTokenClaims
+	token, error := jwt.ParseWithClaims(tokenStr, &claims, getVerificationKey)
 	if error != nil {
-		return 0, error
+		return AccessToken{}, error
 	}
 
-	if permissions, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, error := strconv.ParseUint(fmt.Sprintf("%.0f", permissions["userId"]), 10, 64)
-		if error != nil {
-			return 0, error
-		}
-		return userID, nil
+	if !token.Valid || claims.Purpose != "" || claims.UserID == 0 || claims.SessionID == 0 {
+		return AccessToken{}, errInvalidToken
 	}
-	return 0, errors.New("invalid token")
+
+	return AccessToken{
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		Scopes:    claims.Scopes,
+		Role:      claims.Role,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
 }
@@ -5,14 +5,63 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
 )
 
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
 var (
-	StringConnectDB = ""
-	Port            = 0
-	SecretKey       []byte
+	StringConnectDB           = ""
+	Port                      = 0
+	SecretKey                 []byte
+	JWTSigningKeyFile         = ""
+	JWTVerificationKeyFiles   []string
+	JWTAcceptHS256            = false
+	AccessTokenDuration       = 15 * time.Minute
+	RefreshTokenDuration      = 30 * 24 * time.Hour
+	ChallengeTokenDuration    = 5 * time.Minute
+	TwoFactorMaxAttempts      = 5
+	TOTPIssuer                = "social-network"
+	PasswordResetDuration     = time.Hour
+	EmailVerificationDuration = 24 * time.Hour
+	AppURL                    = ""
+	MailDriver                = "log"
+	MailFrom                  = ""
+	MailFile                  = "mail.log"
+	SMTPHost                  = ""
+	SMTPPort                  = 587
+	SMTPUsername              = ""
+	SMTPPassword              = ""
+	LoginAttemptsStore        = "mysql"
+	LoginLockoutThreshold     = 5
+	LoginIPLockoutThreshold   = 25
+	LoginLockoutBaseDelay     = time.Minute
+	LoginLockoutMaxDelay      = time.Hour
+	LoginAttemptsWindow       = 15 * time.Minute
+	OIDCProviders             = map[string]OIDCProvider{}
+	OIDCStateDuration         = 10 * time.Minute
+	OAuthCodeDuration         = 5 * time.Minute
+	MagicLinkDuration         = 15 * time.Minute
+	PasswordHasher            = "argon2id"
+	Argon2Memory              = 64 * 1024
+	Argon2Time                = 1
+	Argon2Threads             = 2
+	BcryptCost                = 10
+	PasswordMinLength         = 8
+	PasswordMinScore          = 2
+	BreachedPasswordsDir      = ""
+	ReactionKinds             = []string{"heart", "laugh", "wow", "sad", "angry", "celebrate"}
+	TrendingWindows           = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+	TrendingRefreshInterval   = 5 * time.Minute
 )
 
 func Load() {
@@ -32,4 +81,140 @@ func Load() {
 	)
 
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+	JWTSigningKeyFile = os.Getenv("JWT_SIGNING_KEY_FILE")
+	JWTVerificationKeyFiles = getList("JWT_VERIFICATION_KEY_FILES")
+	JWTAcceptHS256 = getBool("JWT_ACCEPT_HS256", JWTAcceptHS256)
+
+	AccessTokenDuration = getDuration("ACCESS_TOKEN_DURATION", AccessTokenDuration)
+	RefreshTokenDuration = getDuration("REFRESH_TOKEN_DURATION", RefreshTokenDuration)
+	ChallengeTokenDuration = getDuration("CHALLENGE_TOKEN_DURATION", ChallengeTokenDuration)
+	TwoFactorMaxAttempts = getPositiveInt("TWO_FACTOR_MAX_ATTEMPTS", TwoFactorMaxAttempts)
+
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		TOTPIssuer = issuer
+	}
+
+	PasswordResetDuration = getDuration("PASSWORD_RESET_DURATION", PasswordResetDuration)
+	EmailVerificationDuration = getDuration("EMAIL_VERIFICATION_DURATION", EmailVerificationDuration)
+	MagicLinkDuration = getDuration("MAGIC_LINK_DURATION", MagicLinkDuration)
+
+	PasswordHasher = getString("PASSWORD_HASHER", PasswordHasher)
+	Argon2Memory = getPositiveInt("ARGON2_MEMORY", Argon2Memory)
+	Argon2Time = getPositiveInt("ARGON2_TIME", Argon2Time)
+	// Argon2 takes the threads as a single byte.
+	Argon2Threads = getIntInRange("ARGON2_THREADS", Argon2Threads, 1, 255)
+	// Below the minimum bcrypt silently uses its default cost, so every hash
+	// would look outdated, and above the maximum hashing fails.
+	BcryptCost = getIntInRange("BCRYPT_COST", BcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+
+	PasswordMinLength = getInt("PASSWORD_MIN_LENGTH", PasswordMinLength)
+	PasswordMinScore = getIntInRange("PASSWORD_MIN_SCORE", PasswordMinScore, 0, 4)
+	BreachedPasswordsDir = os.Getenv("BREACHED_PASSWORDS_DIR")
+
+	if kinds := getList("REACTION_KINDS"); len(kinds) > 0 {
+		ReactionKinds = kinds
+	}
+
+	if windows := getDurationList("TRENDING_WINDOWS"); len(windows) > 0 {
+		TrendingWindows = windows
+	}
+	TrendingRefreshInterval = getDuration("TRENDING_REFRESH_INTERVAL", TrendingRefreshInterval)
+
+	AppURL = getString("APP_URL", fmt.Sprintf("http://localhost:%d", Port))
+
+	MailDriver = getString("MAIL_DRIVER", MailDriver)
+	MailFrom = getString("MAIL_FROM", "no-reply@localhost")
+	MailFile = getString("MAIL_FILE", MailFile)
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = getInt("SMTP_PORT", SMTPPort)
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	LoginAttemptsStore = getString("LOGIN_ATTEMPTS_STORE", LoginAttemptsStore)
+	LoginLockoutThreshold = getInt("LOGIN_LOCKOUT_THRESHOLD", LoginLockoutThreshold)
+	LoginIPLockoutThreshold = getInt("LOGIN_IP_LOCKOUT_THRESHOLD", LoginIPLockoutThreshold)
+	LoginLockoutBaseDelay = getDuration("LOGIN_LOCKOUT_BASE_DELAY", LoginLockoutBaseDelay)
+	LoginLockoutMaxDelay = getDuration("LOGIN_LOCKOUT_MAX_DELAY", LoginLockoutMaxDelay)
+	LoginAttemptsWindow = getDuration("LOGIN_ATTEMPTS_WINDOW", LoginAttemptsWindow)
+
+	OIDCStateDuration = getDuration("OIDC_STATE_DURATION", OIDCStateDuration)
+	OAuthCodeDuration = getDuration("OAUTH_CODE_DURATION", OAuthCodeDuration)
+	OIDCProviders = map[string]OIDCProvider{}
+	for _, name := range getList("OIDC_PROVIDERS") {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		OIDCProviders[name] = OIDCProvider{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  getString(prefix+"REDIRECT_URL", fmt.Sprintf("%s/auth/oidc/%s/callback", AppURL, name)),
+		}
+	}
+}
+
+// getDuration falls back to the default for durations that aren't positive,
+// which would otherwise expire everything at once or make tickers panic.
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	duration, error := time.ParseDuration(os.Getenv(key))
+	if error != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
+}
+
+func getString(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getInt(key string, defaultValue int) int {
+	value, error := strconv.Atoi(os.Getenv(key))
+	if error != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getPositiveInt(key string, defaultValue int) int {
+	if value := getInt(key, defaultValue); value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+func getBool(key string, defaultValue bool) bool {
+	value, error := strconv.ParseBool(os.Getenv(key))
+	if error != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getIntInRange falls back to the default for values outside min..max.
+func getIntInRange(key string, defaultValue, min, max int) int {
+	if value := getInt(key, defaultValue); value >= min && value <= max {
+		return value
+	}
+	return defaultValue
+}
+
+func getList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getDurationList(key string) []time.Duration {
+	var durations []time.Duration
+	for _, value := range getList(key) {
+		if duration, error := time.ParseDuration(value); error == nil && duration > 0 {
+			durations = append(durations, duration)
+		}
+	}
+	return durations
 }
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetPositiveInt(t *testing.T) {
+	tests := map[string]int{
+		"":    4,
+		"x":   4,
+		"0":   4,
+		"-1":  4,
+		"1":   1,
+		"256": 256,
+	}
+
+	for value, want := range tests {
+		t.Setenv("TEST_INT", value)
+		if got := getPositiveInt("TEST_INT", 4); got != want {
+			t.Errorf("getPositiveInt(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestGetDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":    time.Hour,
+		"x":   time.Hour,
+		"0s":  time.Hour,
+		"-5m": time.Hour,
+		"90s": 90 * time.Second,
+	}
+
+	for value, want := range tests {
+		t.Setenv("TEST_DURATION", value)
+		if got := getDuration("TEST_DURATION", time.Hour); got != want {
+			t.Errorf("getDuration(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestGetIntInRange(t *testing.T) {
+	tests := map[string]int{
+		"":   10,
+		"x":  10,
+		"3":  10,
+		"4":  4,
+		"31": 31,
+		"32": 10,
+	}
+
+	for value, want := range tests {
+		t.Setenv("TEST_INT", value)
+		if got := getIntInRange("TEST_INT", 10, 4, 31); got != want {
+			t.Errorf("getIntInRange(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	tests := map[string]bool{
+		"":      false,
+		"x":     false,
+		"true":  true,
+		"1":     true,
+		"false": false,
+	}
+
+	for value, want := range tests {
+		t.Setenv("TEST_BOOL", value)
+		if got := getBool("TEST_BOOL", false); got != want {
+			t.Errorf("getBool(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
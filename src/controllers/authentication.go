@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"strings"
+	"time"
+)
+
+var errRefreshTokenReused = errors.New("refresh token reused, session revoked")
+
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var refreshToken models.RefreshToken
+	if error := json.Unmarshal(request, &refreshToken); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	tokens, _, error := refreshSession(db, refreshToken.RefreshToken, "")
+	if error != nil {
+		if error == authentication.ErrInvalidRefreshToken || error == errRefreshTokenReused {
+			responses.Error(w, http.StatusUnauthorized, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, tokens)
+}
+
+func Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, error := authentication.GetSessionID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+	if sessionID == 0 {
+		responses.Error(w, http.StatusBadRequest, errors.New("personal access tokens must be revoked through /users/me/tokens"))
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositorySessions(db)
+	if error := repository.Revoke(sessionID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func issueTokens(db *sql.DB, r *http.Request, userID uint64) (models.Tokens, error) {
+	tokens, _, error := startSession(db, models.Session{
+		UserID:    userID,
+		UserAgent: truncate(r.UserAgent(), 255),
+		IPAddress: clientIP(r),
+		Scopes:    authentication.AllScopes,
+	})
+	return tokens, error
+}
+
+// startSession persists a new session and returns its first access and
+// refresh tokens.
+func startSession(db *sql.DB, session models.Session) (models.Tokens, uint64, error) {
+	secret, error := authentication.GenerateRefreshSecret()
+	if error != nil {
+		return models.Tokens{}, 0, error
+	}
+
+	session.RefreshTokenHash = security.HashToken(secret)
+	session.ExpiresAt = time.Now().Add(config.RefreshTokenDuration)
+
+	repository := repositories.NewRepositorySessions(db)
+	session.ID, error = repository.Create(session)
+	if error != nil {
+		return models.Tokens{}, 0, error
+	}
+
+	tokens, error := sessionTokens(db, session, secret)
+	return tokens, session.ID, error
+}
+
+// refreshSession rotates the refresh token of the session it belongs to. A
+// refresh token that was already rotated is being reused, so the whole
+// session is considered compromised and revoked. Sessions started by an OAuth
+// client can only be refreshed by that same client.
+func refreshSession(db *sql.DB, refreshToken, clientID string) (models.Tokens, models.Session, error) {
+	sessionID, secret, error := authentication.ParseRefreshToken(refreshToken)
+	if error != nil {
+		return models.Tokens{}, models.Session{}, error
+	}
+
+	repository := repositories.NewRepositorySessions(db)
+	session, error := repository.GetSession(sessionID)
+	if error != nil {
+		return models.Tokens{}, models.Session{}, error
+	}
+
+	if session.ID == 0 || !session.Active() || session.ClientID != clientID {
+		return models.Tokens{}, models.Session{}, authentication.ErrInvalidRefreshToken
+	}
+
+	if secretHash := security.HashToken(secret); session.RefreshTokenHash != secretHash {
+		// Session IDs are sequential, so only a secret the session really
+		// had can revoke it. Anything else is just a wrong token.
+		reused, error := repository.WasRotated(session.ID, secretHash)
+		if error != nil {
+			return models.Tokens{}, models.Session{}, error
+		}
+		if !reused {
+			return models.Tokens{}, models.Session{}, authentication.ErrInvalidRefreshToken
+		}
+
+		if error := repository.Revoke(session.ID); error != nil {
+			return models.Tokens{}, models.Session{}, error
+		}
+		return models.Tokens{}, models.Session{}, errRefreshTokenReused
+	}
+
+	newSecret, error := authentication.GenerateRefreshSecret()
+	if error != nil {
+		return models.Tokens{}, models.Session{}, error
+	}
+
+	rotated, error := repository.RotateRefreshToken(session.ID, session.RefreshTokenHash, security.HashToken(newSecret))
+	if error != nil {
+		return models.Tokens{}, models.Session{}, error
+	}
+	if !rotated {
+		return models.Tokens{}, models.Session{}, authentication.ErrInvalidRefreshToken
+	}
+
+	tokens, error := sessionTokens(db, session, newSecret)
+	return tokens, session, error
+}
+
+func sessionTokens(db *sql.DB, session models.Session, secret string) (models.Tokens, error) {
+	// Clients act for the user only within the scopes granted to them, never
+	// with the powers of a moderator or admin.
+	role := models.RoleUser
+	if session.ClientID == "" {
+		userRole, error := repositories.NewRepositoryUsers(db).GetRole(session.UserID)
+		if error != nil {
+			return models.Tokens{}, error
+		}
+		role = userRole
+	}
+
+	accessToken, error := authentication.GenerateToken(session.UserID, session.ID, session.Scopes, role)
+	if error != nil {
+		return models.Tokens{}, error
+	}
+
+	tokens := newTokens(accessToken, session.ID, secret)
+	if session.ClientID != "" {
+		tokens.Scope = strings.Join(session.Scopes, " ")
+	}
+	return tokens, nil
+}
+
+func newTokens(accessToken string, sessionID uint64, secret string) models.Tokens {
+	return models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: authentication.FormatRefreshToken(sessionID, secret),
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(config.AccessTokenDuration.Seconds()),
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, error := net.SplitHostPort(r.RemoteAddr)
+	if error != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func truncate(value string, size int) string {
+	if len(value) > size {
+		return value[:size]
+	}
+	return value
+}
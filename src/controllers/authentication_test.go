@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+)
+
+var sessionColumns = []string{
+	"id", "user_id", "refresh_token_hash", "user_agent", "ip_address", "scopes", "client_id",
+	"expires_at", "revoked_at", "created_at",
+}
+
+func useTestSecret(t *testing.T) {
+	t.Helper()
+	secretKey := config.SecretKey
+	config.SecretKey = []byte("test secret")
+	t.Cleanup(func() { config.SecretKey = secretKey })
+}
+
+// useCheapHasher hashes passwords with cheap parameters, so tests don't
+// spend seconds on them.
+func useCheapHasher(t *testing.T) {
+	t.Helper()
+	hasher, memory, time, threads := config.PasswordHasher, config.Argon2Memory, config.Argon2Time, config.Argon2Threads
+	config.PasswordHasher, config.Argon2Memory, config.Argon2Time, config.Argon2Threads = "argon2id", 1024, 1, 1
+	t.Cleanup(func() {
+		config.PasswordHasher, config.Argon2Memory, config.Argon2Time, config.Argon2Threads = hasher, memory, time, threads
+	})
+}
+
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, error := sqlmock.New()
+	if error != nil {
+		t.Fatal(error)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if error := mock.ExpectationsWereMet(); error != nil {
+			t.Error(error)
+		}
+	})
+	return db, mock
+}
+
+// expectConnection makes the next database.Connect of the handlers open a
+// mock. The mock goes away once the handler closes its connection, so each
+// request needs its own.
+func expectConnection(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	dsn := fmt.Sprintf("%s %d", t.Name(), time.Now().UnixNano())
+	db, mock, error := sqlmock.NewWithDSN(dsn)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	driver, stringConnectDB := database.Driver, config.StringConnectDB
+	database.Driver, config.StringConnectDB = "sqlmock", dsn
+	t.Cleanup(func() {
+		database.Driver, config.StringConnectDB = driver, stringConnectDB
+		db.Close()
+		if error := mock.ExpectationsWereMet(); error != nil {
+			t.Error(error)
+		}
+	})
+	return mock
+}
+
+// authenticatedRequest builds the request the authentication middleware
+// would hand to the handlers, with the route variables mux would set.
+func authenticatedRequest(method, target, body string, principal authentication.Principal, vars map[string]string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if vars != nil {
+		r = mux.SetURLVars(r, vars)
+	}
+	return authentication.WithPrincipal(r, principal)
+}
+
+// capturedArgument matches any string argument and keeps it, for values the
+// handlers generate.
+type capturedArgument struct {
+	value *string
+}
+
+func (argument capturedArgument) Match(value driver.Value) bool {
+	*argument.value, _ = value.(string)
+	return true
+}
+
+func expectSession(mock sqlmock.Sqlmock, sessionID uint64, secret, clientID string, expiresAt time.Time, revokedAt *time.Time) {
+	mock.ExpectQuery("from sessions where id = ").
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows(sessionColumns).AddRow(
+			sessionID, 7, security.HashToken(secret), "test", "127.0.0.1", "posts:read posts:write", clientID,
+			expiresAt, revokedAt, time.Now(),
+		))
+}
+
+func expectRotated(mock sqlmock.Sqlmock, sessionID uint64, secret string, rotated bool) {
+	mock.ExpectQuery("from rotated_refresh_tokens").
+		WithArgs(sessionID, security.HashToken(secret)).
+		WillReturnRows(sqlmock.NewRows([]string{"rotated"}).AddRow(rotated))
+}
+
+func TestRefreshSessionRotatesRefreshToken(t *testing.T) {
+	useTestSecret(t)
+	db, mock := newMockDB(t)
+
+	expectSession(mock, 3, "secret", "", time.Now().Add(time.Hour), nil)
+	mock.ExpectBegin()
+	mock.ExpectExec("update sessions set refresh_token_hash").
+		WithArgs(sqlmock.AnyArg(), 3, security.HashToken("secret")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("insert into rotated_refresh_tokens").
+		WithArgs(security.HashToken("secret"), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("select role from users").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("user"))
+
+	tokens, session, error := refreshSession(db, authentication.FormatRefreshToken(3, "secret"), "")
+	if error != nil {
+		t.Fatal(error)
+	}
+	if session.ID != 3 {
+		t.Errorf("session = %d, want 3", session.ID)
+	}
+
+	sessionID, newSecret, error := authentication.ParseRefreshToken(tokens.RefreshToken)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if sessionID != 3 || newSecret == "secret" {
+		t.Errorf("refresh token not rotated: %s", tokens.RefreshToken)
+	}
+
+	accessToken, error := authentication.ParseAccessToken(tokens.AccessToken)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if accessToken.UserID != 7 || accessToken.SessionID != 3 || len(accessToken.Scopes) != 2 {
+		t.Errorf("unexpected access token %+v", accessToken)
+	}
+}
+
+func TestRefreshSessionRevokesSessionOnReuse(t *testing.T) {
+	useTestSecret(t)
+	db, mock := newMockDB(t)
+
+	expectSession(mock, 3, "rotated secret", "", time.Now().Add(time.Hour), nil)
+	expectRotated(mock, 3, "old secret", true)
+	mock.ExpectPrepare("update sessions set revoked_at").
+		ExpectExec().
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, _, error := refreshSession(db, authentication.FormatRefreshToken(3, "old secret"), ""); error != errRefreshTokenReused {
+		t.Fatalf("error = %v, want %v", error, errRefreshTokenReused)
+	}
+}
+
+// Session IDs can be guessed, so a made up secret must not log the user out.
+func TestRefreshSessionKeepsSessionOnWrongSecret(t *testing.T) {
+	useTestSecret(t)
+	db, mock := newMockDB(t)
+
+	expectSession(mock, 3, "secret", "", time.Now().Add(time.Hour), nil)
+	expectRotated(mock, 3, "garbage", false)
+
+	if _, _, error := refreshSession(db, authentication.FormatRefreshToken(3, "garbage"), ""); error != authentication.ErrInvalidRefreshToken {
+		t.Fatalf("error = %v, want %v", error, authentication.ErrInvalidRefreshToken)
+	}
+}
+
+func TestRefreshTokenRejectsGuessedToken(t *testing.T) {
+	useTestSecret(t)
+	mock := expectConnection(t)
+	expectSession(mock, 3, "secret", "", time.Now().Add(time.Hour), nil)
+	expectRotated(mock, 3, "garbage", false)
+
+	request := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(`{"refresh_token": "3.garbage"}`))
+	response := httptest.NewRecorder()
+	RefreshToken(response, request)
+	if response.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+}
+
+func TestRefreshSessionLosesConcurrentRotation(t *testing.T) {
+	useTestSecret(t)
+	db, mock := newMockDB(t)
+
+	expectSession(mock, 3, "secret", "", time.Now().Add(time.Hour), nil)
+	mock.ExpectBegin()
+	mock.ExpectExec("update sessions set refresh_token_hash").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if _, _, error := refreshSession(db, authentication.FormatRefreshToken(3, "secret"), ""); error != authentication.ErrInvalidRefreshToken {
+		t.Fatalf("error = %v, want %v", error, authentication.ErrInvalidRefreshToken)
+	}
+}
+
+func TestRefreshSessionRejectsInactiveSessions(t *testing.T) {
+	revokedAt := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name      string
+		clientID  string
+		expiresAt time.Time
+		revokedAt *time.Time
+	}{
+		{name: "expired", expiresAt: time.Now().Add(-time.Minute)},
+		{name: "revoked", expiresAt: time.Now().Add(time.Hour), revokedAt: &revokedAt},
+		{name: "other client", clientID: "client", expiresAt: time.Now().Add(time.Hour)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestSecret(t)
+			db, mock := newMockDB(t)
+
+			expectSession(mock, 3, "secret", test.clientID, test.expiresAt, test.revokedAt)
+
+			if _, _, error := refreshSession(db, authentication.FormatRefreshToken(3, "secret"), ""); error != authentication.ErrInvalidRefreshToken {
+				t.Fatalf("error = %v, want %v", error, authentication.ErrInvalidRefreshToken)
+			}
+		})
+	}
+}
+
+func TestRefreshSessionRejectsMalformedTokens(t *testing.T) {
+	db, _ := newMockDB(t)
+
+	for _, refreshToken := range []string{"", "secret", "3.", "x.secret"} {
+		if _, _, error := refreshSession(db, refreshToken, ""); error != authentication.ErrInvalidRefreshToken {
+			t.Errorf("refreshSession(%q) error = %v, want %v", refreshToken, error, authentication.ErrInvalidRefreshToken)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+)
+
+var errCollectionNotFound = errors.New("collection not found")
+
+// BookmarkPost saves the post for the user. The body may name one of the
+// collections of the user; bookmarking an already bookmarked post moves it
+// there.
+func BookmarkPost(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var bookmark models.Bookmark
+	if len(request) > 0 {
+		if error := json.Unmarshal(request, &bookmark); error != nil {
+			responses.Error(w, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	post, error := repositories.NewRepositoryPosts(db).GetPost(postID, 0)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	repository := repositories.NewRepositoryBookmarks(db)
+	if bookmark.CollectionID != nil {
+		collection, error := repository.GetCollection(*bookmark.CollectionID, userID)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		if collection.ID == 0 {
+			responses.Error(w, http.StatusUnprocessableEntity, errCollectionNotFound)
+			return
+		}
+	}
+
+	if error := repository.Add(userID, postID, bookmark.CollectionID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func UnbookmarkPost(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryBookmarks(db)
+	if error := repository.Remove(userID, postID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// ListBookmarks returns the posts the user saved, optionally only the ones in
+// the collection given by collection_id.
+func ListBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	var collectionID *uint64
+	if value := r.URL.Query().Get("collection_id"); value != "" {
+		ID, error := strconv.ParseUint(value, 10, 64)
+		if error != nil {
+			responses.Error(w, http.StatusBadRequest, error)
+			return
+		}
+		collectionID = &ID
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if collectionID != nil {
+		collection, error := repositories.NewRepositoryBookmarks(db).GetCollection(*collectionID, userID)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		if collection.ID == 0 {
+			responses.Error(w, http.StatusNotFound, errCollectionNotFound)
+			return
+		}
+	}
+
+	repository := repositories.NewRepositoryPosts(db)
+	posts, error := repository.GetBookmarkedPosts(userID, collectionID, limit, offset)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+func CreateBookmarkCollection(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var collection models.BookmarkCollection
+	if error := json.Unmarshal(request, &collection); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+	collection.UserID = userID
+
+	if error := collection.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryBookmarks(db)
+	collection.ID, error = repository.CreateCollection(collection)
+	if error != nil {
+		if isDuplicateEntry(error) {
+			responses.Error(w, http.StatusConflict, errors.New("collection already exists"))
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, collection)
+}
+
+func ListBookmarkCollections(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryBookmarks(db)
+	collections, error := repository.ListCollections(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, collections)
+}
+
+func UpdateBookmarkCollection(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	collectionID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var collection models.BookmarkCollection
+	if error := json.Unmarshal(request, &collection); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := collection.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryBookmarks(db)
+	existing, error := repository.GetCollection(collectionID, userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if existing.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errCollectionNotFound)
+		return
+	}
+
+	if error := repository.UpdateCollection(collectionID, userID, collection.Name); error != nil {
+		if isDuplicateEntry(error) {
+			responses.Error(w, http.StatusConflict, errors.New("collection already exists"))
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeleteBookmarkCollection removes the collection but keeps the bookmarks
+// that were in it.
+func DeleteBookmarkCollection(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	collectionID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryBookmarks(db)
+	collection, error := repository.GetCollection(collectionID, userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if collection.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errCollectionNotFound)
+		return
+	}
+
+	if error := repository.DeleteCollection(collectionID, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// isDuplicateEntry tells whether the error is MySQL refusing a row that
+// breaks a unique key.
+func isDuplicateEntry(error error) bool {
+	var mysqlError *mysql.MySQLError
+	return errors.As(error, &mysqlError) && mysqlError.Number == 1062
+}
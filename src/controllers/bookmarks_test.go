@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var collectionColumns = []string{"id", "user_id", "name", "bookmarks_count", "created_at"}
+
+// expectCollection looks up the collection 3 for the user 9, which only
+// finds it when it belongs to them.
+func expectCollection(mock sqlmock.Sqlmock, found bool) {
+	rows := sqlmock.NewRows(collectionColumns)
+	if found {
+		rows.AddRow(3, 9, "Recipes", 2, time.Now())
+	}
+	mock.ExpectQuery("from bookmark_collections c").WithArgs(3, 9).WillReturnRows(rows)
+}
+
+func bookmarkRequest(method, target, body string, vars map[string]string) *http.Request {
+	return authenticatedRequest(method, target, body, authentication.Principal{UserID: 9, Role: "user"}, vars)
+}
+
+func TestBookmarkPost(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		collectionID interface{}
+		collection   bool
+		statusCode   int
+	}{
+		{"without a collection", "", nil, false, http.StatusNoContent},
+		{"in a collection", `{"collection_id": 3}`, 3, true, http.StatusNoContent},
+		// The collections of other users can't be told apart from missing ones.
+		{"in a collection of someone else", `{"collection_id": 3}`, 3, false, http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			expectPost(mock, postRows(0, 1))
+			if test.collectionID != nil {
+				expectCollection(mock, test.collection)
+			}
+			if test.statusCode == http.StatusNoContent {
+				mock.ExpectPrepare("insert into bookmarks").
+					ExpectExec().
+					WithArgs(9, 1, test.collectionID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			response := httptest.NewRecorder()
+			BookmarkPost(response, bookmarkRequest(http.MethodPost, "/posts/1/bookmark", test.body, map[string]string{"id": "1"}))
+			if response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
+
+func TestBookmarkMissingPost(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+
+	response := httptest.NewRecorder()
+	BookmarkPost(response, bookmarkRequest(http.MethodPost, "/posts/1/bookmark", "", map[string]string{"id": "1"}))
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
+
+func TestUnbookmarkPost(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectPrepare("delete from bookmarks").
+		ExpectExec().
+		WithArgs(9, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	response := httptest.NewRecorder()
+	UnbookmarkPost(response, bookmarkRequest(http.MethodDelete, "/posts/1/bookmark", "", map[string]string{"id": "1"}))
+	if response.Code != http.StatusNoContent {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+}
+
+func TestListBookmarks(t *testing.T) {
+	t.Run("all", func(t *testing.T) {
+		mock := expectConnection(t)
+		mock.ExpectQuery("from bookmarks b").
+			WithArgs(9, nil, nil, 20, 0).
+			WillReturnRows(postRows(0, 2, 1))
+		expectPostDetails(mock)
+
+		response := httptest.NewRecorder()
+		ListBookmarks(response, bookmarkRequest(http.MethodGet, "/users/me/bookmarks", "", nil))
+		if response.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", response.Code, response.Body)
+		}
+		var posts []models.Post
+		if error := json.NewDecoder(response.Body).Decode(&posts); error != nil {
+			t.Fatal(error)
+		}
+		if len(posts) != 2 || posts[0].ID != 2 || posts[1].ID != 1 {
+			t.Errorf("unexpected posts %+v", posts)
+		}
+	})
+
+	t.Run("page of a collection", func(t *testing.T) {
+		mock := expectConnection(t)
+		expectCollection(mock, true)
+		mock.ExpectQuery("from bookmarks b").
+			WithArgs(9, 3, 3, 5, 10).
+			WillReturnRows(sqlmock.NewRows(postColumns))
+
+		response := httptest.NewRecorder()
+		ListBookmarks(response, bookmarkRequest(http.MethodGet, "/users/me/bookmarks?collection_id=3&limit=5&offset=10", "", nil))
+		if response.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", response.Code, response.Body)
+		}
+	})
+
+	t.Run("collection of someone else", func(t *testing.T) {
+		expectCollection(expectConnection(t), false)
+
+		response := httptest.NewRecorder()
+		ListBookmarks(response, bookmarkRequest(http.MethodGet, "/users/me/bookmarks?collection_id=3", "", nil))
+		if response.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		ListBookmarks(response, bookmarkRequest(http.MethodGet, "/users/me/bookmarks?limit=1000", "", nil))
+		if response.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+		}
+	})
+}
+
+func TestCreateBookmarkCollection(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectPrepare("insert into bookmark_collections").
+		ExpectExec().
+		WithArgs(9, "Recipes").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	response := httptest.NewRecorder()
+	CreateBookmarkCollection(response, bookmarkRequest(http.MethodPost, "/users/me/bookmarks/collections", `{"name": " Recipes "}`, nil))
+	if response.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var collection models.BookmarkCollection
+	if error := json.NewDecoder(response.Body).Decode(&collection); error != nil {
+		t.Fatal(error)
+	}
+	if collection.ID != 3 || collection.Name != "Recipes" {
+		t.Errorf("unexpected collection %+v", collection)
+	}
+}
+
+func TestListBookmarkCollections(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("from bookmark_collections c").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows(collectionColumns).
+			AddRow(4, 9, "Articles", 0, time.Now()).
+			AddRow(3, 9, "Recipes", 2, time.Now()))
+
+	response := httptest.NewRecorder()
+	ListBookmarkCollections(response, bookmarkRequest(http.MethodGet, "/users/me/bookmarks/collections", "", nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var collections []models.BookmarkCollection
+	if error := json.NewDecoder(response.Body).Decode(&collections); error != nil {
+		t.Fatal(error)
+	}
+	if len(collections) != 2 || collections[1].BookmarksCount != 2 {
+		t.Errorf("unexpected collections %+v", collections)
+	}
+}
+
+func TestUpdateBookmarkCollection(t *testing.T) {
+	tests := []struct {
+		name       string
+		found      bool
+		statusCode int
+	}{
+		{"own collection", true, http.StatusNoContent},
+		{"collection of someone else", false, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			expectCollection(mock, test.found)
+			if test.found {
+				mock.ExpectPrepare("update bookmark_collections set name").
+					ExpectExec().
+					WithArgs("Cooking", 3, 9).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			response := httptest.NewRecorder()
+			UpdateBookmarkCollection(response, bookmarkRequest(
+				http.MethodPut, "/users/me/bookmarks/collections/3", `{"name": "Cooking"}`, map[string]string{"id": "3"},
+			))
+			if response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
+
+func TestDeleteBookmarkCollection(t *testing.T) {
+	tests := []struct {
+		name       string
+		found      bool
+		statusCode int
+	}{
+		{"own collection", true, http.StatusNoContent},
+		{"collection of someone else", false, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			expectCollection(mock, test.found)
+			if test.found {
+				mock.ExpectPrepare("delete from bookmark_collections").
+					ExpectExec().
+					WithArgs(3, 9).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			response := httptest.NewRecorder()
+			DeleteBookmarkCollection(response, bookmarkRequest(
+				http.MethodDelete, "/users/me/bookmarks/collections/3", "", map[string]string{"id": "3"},
+			))
+			if response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
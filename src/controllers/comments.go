@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+var errCommentNotFound = errors.New("comment not found")
+
+func CreateComment(w http.ResponseWriter, r *http.Request) {
+	authorID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var comment models.Comment
+	if error := json.Unmarshal(request, &comment); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+	comment.PostID = postID
+	comment.AuthorID = authorID
+
+	if error := comment.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireVerifiedEmail(db, authorID); error != nil {
+		if error == errEmailNotVerified {
+			responses.Error(w, http.StatusForbidden, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if post, error := repositories.NewRepositoryPosts(db).GetPost(postID, authorID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	repository := repositories.NewRepositoryComments(db)
+	commentID, error := repository.Create(comment)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	comment, error = repository.GetComment(commentID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusCreated, comment)
+}
+
+func ListComments(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if post, error := repositories.NewRepositoryPosts(db).GetPost(postID, 0); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	comments, error := repositories.NewRepositoryComments(db).ListForPost(postID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, comments)
+}
+
+func UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	commentID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var comment models.Comment
+	if error := json.Unmarshal(request, &comment); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := comment.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireVerifiedEmail(db, userID); error != nil {
+		if error == errEmailNotVerified {
+			responses.Error(w, http.StatusForbidden, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	repository := repositories.NewRepositoryComments(db)
+	if actualComment, error := repository.GetComment(commentID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if actualComment.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errCommentNotFound)
+		return
+	} else if actualComment.AuthorID != userID {
+		responses.Error(w, http.StatusForbidden, errors.New("it's only allowed to update a comment of your authorship"))
+		return
+	}
+
+	if error := repository.UpdateComment(commentID, comment.Content); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeleteComment lets the author of the post moderate the conversation on it,
+// besides the author of the comment and the moderators.
+func DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	commentID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryComments(db)
+	comment, error := repository.GetComment(commentID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if comment.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errCommentNotFound)
+		return
+	}
+
+	if comment.AuthorID != userID && !authentication.IsModerator(r) {
+		post, error := repositories.NewRepositoryPosts(db).GetPost(comment.PostID, userID)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		if post.AuthorID != userID {
+			responses.Error(w, http.StatusForbidden, errors.New("it's only allowed to delete your comments or comments on your posts"))
+			return
+		}
+	}
+
+	if error := repository.DeleteComment(commentID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
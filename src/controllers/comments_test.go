@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var commentColumns = []string{"id", "post_id", "author_id", "nick", "content", "created_at", "updated_at"}
+
+// expectComment expects the comment 5 of the user 8 on the post 1, which
+// belongs to the user 7, or no comment when found is false.
+func expectComment(mock sqlmock.Sqlmock, found bool) {
+	rows := sqlmock.NewRows(commentColumns)
+	if found {
+		rows.AddRow(5, 1, 8, "john", "comment", time.Now(), nil)
+	}
+	mock.ExpectQuery("from comments c").WithArgs(5).WillReturnRows(rows)
+}
+
+func commentRequest(method, body string, userID uint64, role string, vars map[string]string) *http.Request {
+	return authenticatedRequest(method, "/", body, authentication.Principal{UserID: userID, Role: role}, vars)
+}
+
+func TestCreateComment(t *testing.T) {
+	mock := expectConnection(t)
+	expectEmailStatus(mock, 8, true)
+	expectPost(mock, postRows(0, 1))
+	mock.ExpectPrepare("insert into comments").
+		ExpectExec().
+		WithArgs(1, 8, "comment").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	expectComment(mock, true)
+
+	response := httptest.NewRecorder()
+	CreateComment(response, commentRequest(http.MethodPost, `{"content": "  comment "}`, 8, "user", map[string]string{"id": "1"}))
+	if response.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+
+	var comment models.Comment
+	if error := json.NewDecoder(response.Body).Decode(&comment); error != nil {
+		t.Fatal(error)
+	}
+	if comment.ID != 5 || comment.PostID != 1 || comment.AuthorNick != "john" {
+		t.Errorf("unexpected comment %+v", comment)
+	}
+}
+
+func TestCreateCommentOnMissingPost(t *testing.T) {
+	mock := expectConnection(t)
+	expectEmailStatus(mock, 8, true)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+
+	response := httptest.NewRecorder()
+	CreateComment(response, commentRequest(http.MethodPost, `{"content": "comment"}`, 8, "user", map[string]string{"id": "1"}))
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
+
+// Only the author edits a comment, not even the author of the post.
+func TestUpdateComment(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint64
+		role       string
+		found      bool
+		statusCode int
+	}{
+		{"author", 8, "user", true, http.StatusNoContent},
+		{"post author", 7, "user", true, http.StatusForbidden},
+		{"moderator", 9, "moderator", true, http.StatusForbidden},
+		{"missing", 8, "user", false, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			expectEmailStatus(mock, test.userID, true)
+			expectComment(mock, test.found)
+			if test.statusCode == http.StatusNoContent {
+				mock.ExpectPrepare("update comments set content").
+					ExpectExec().
+					WithArgs("edited", 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			response := httptest.NewRecorder()
+			UpdateComment(response, commentRequest(http.MethodPut, `{"content": "edited"}`, test.userID, test.role, map[string]string{"id": "5"}))
+			if response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
+
+// Besides its author and the moderators, the author of the post moderates
+// the comments on it.
+func TestDeleteComment(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint64
+		role       string
+		found      bool
+		getsPost   bool
+		statusCode int
+	}{
+		{"author", 8, "user", true, false, http.StatusNoContent},
+		{"post author", 7, "user", true, true, http.StatusNoContent},
+		{"moderator", 9, "moderator", true, false, http.StatusNoContent},
+		{"another user", 9, "user", true, true, http.StatusForbidden},
+		{"missing", 8, "user", false, false, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			expectComment(mock, test.found)
+			if test.getsPost {
+				expectPost(mock, postRows(0, 1))
+			}
+			if test.statusCode == http.StatusNoContent {
+				mock.ExpectPrepare("delete from comments").
+					ExpectExec().
+					WithArgs(5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			response := httptest.NewRecorder()
+			DeleteComment(response, commentRequest(http.MethodDelete, "", test.userID, test.role, map[string]string{"id": "5"}))
+			if response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
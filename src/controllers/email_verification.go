@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/mailer"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"time"
+)
+
+var (
+	errInvalidVerificationToken = errors.New("invalid or expired verification token")
+	errEmailNotVerified         = errors.New("e-mail not verified")
+	errEmailTaken               = errors.New("e-mail already in use")
+)
+
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var verifyEmail models.VerifyEmail
+	if error := json.Unmarshal(request, &verifyEmail); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := verifyEmail.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryEmailVerifications(db)
+	emailVerification, error := repository.GetForTokenHash(security.HashToken(verifyEmail.Token))
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if !emailVerification.Valid() {
+		responses.Error(w, http.StatusBadRequest, errInvalidVerificationToken)
+		return
+	}
+
+	if confirmed, error := repository.Confirm(emailVerification); error != nil {
+		if isDuplicateEntry(error) {
+			responses.Error(w, http.StatusConflict, errEmailTaken)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if !confirmed {
+		responses.Error(w, http.StatusBadRequest, errInvalidVerificationToken)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	user, error := repositories.NewRepositoryUsers(db).GetEmailStatus(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	email := user.PendingEmail
+	if email == "" {
+		if user.EmailVerifiedAt != nil {
+			responses.Error(w, http.StatusConflict, errors.New("e-mail already verified"))
+			return
+		}
+		email = user.Email
+	}
+
+	if error := sendEmailVerification(db, userID, email); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+func sendEmailVerification(db *sql.DB, userID uint64, email string) error {
+	token, error := security.RandomToken(32)
+	if error != nil {
+		return error
+	}
+
+	repository := repositories.NewRepositoryEmailVerifications(db)
+	if _, error := repository.Create(models.EmailVerification{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: security.HashToken(token),
+		ExpiresAt: time.Now().Add(config.EmailVerificationDuration),
+	}); error != nil {
+		return error
+	}
+
+	message := mailer.Message{
+		To:      email,
+		Subject: "Confirm your e-mail",
+		Body: fmt.Sprintf(
+			"Use the link below to confirm this e-mail address. It expires in %s.\n\n%s/verify-email?token=%s\n",
+			config.EmailVerificationDuration,
+			config.AppURL,
+			url.QueryEscape(token),
+		),
+	}
+	go func() {
+		if error := mailer.New().Send(message); error != nil {
+			log.Printf("\nsending e-mail verification: %v", error)
+		}
+	}()
+
+	return nil
+}
+
+func requireVerifiedEmail(db *sql.DB, userID uint64) error {
+	user, error := repositories.NewRepositoryUsers(db).GetEmailStatus(userID)
+	if error != nil {
+		return error
+	}
+	if user.EmailVerifiedAt == nil {
+		return errEmailNotVerified
+	}
+	return nil
+}
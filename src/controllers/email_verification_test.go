@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func expectEmailStatus(mock sqlmock.Sqlmock, userID uint64, verified bool) {
+	var verifiedAt *time.Time
+	if verified {
+		now := time.Now()
+		verifiedAt = &now
+	}
+	mock.ExpectQuery("email_verified_at from users where id = ").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "pending_email", "email_verified_at"}).
+			AddRow(userID, "jane@example.com", "", verifiedAt))
+}
+
+// Every write that publishes content is turned down for an unverified
+// account before it touches the post.
+func TestPublishingRequiresVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		body    string
+		vars    map[string]string
+	}{
+		{"create post", CreatePost, http.MethodPost, `{"title": "Title", "content": "post"}`, nil},
+		{"update post", UpdatePost, http.MethodPut, `{"title": "Title", "content": "post"}`, map[string]string{"id": "1"}},
+		{"repost", Repost, http.MethodPost, "", map[string]string{"id": "1"}},
+		{"like", LikePost, http.MethodPost, "", map[string]string{"id": "1"}},
+		{"react", AddReaction, http.MethodPut, "", map[string]string{"id": "1", "kind": "heart"}},
+		{"create comment", CreateComment, http.MethodPost, `{"content": "comment"}`, map[string]string{"id": "1"}},
+		{"update comment", UpdateComment, http.MethodPut, `{"content": "comment"}`, map[string]string{"id": "1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expectEmailStatus(expectConnection(t), 9, false)
+
+			response := httptest.NewRecorder()
+			test.handler(response, authenticatedRequest(
+				test.method, "/", test.body,
+				authentication.Principal{UserID: 9, Role: "user"},
+				test.vars,
+			))
+			if response.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusForbidden, response.Body)
+			}
+		})
+	}
+}
+
+func TestLikePostWithVerifiedEmail(t *testing.T) {
+	mock := expectConnection(t)
+	expectEmailStatus(mock, 9, true)
+	expectPost(mock, postRows(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("insert ignore into post_likes").WithArgs(9, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("update posts set likes = likes").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	response := httptest.NewRecorder()
+	LikePost(response, authenticatedRequest(
+		http.MethodPost, "/posts/1/like", "",
+		authentication.Principal{UserID: 9, Role: "user"},
+		map[string]string{"id": "1"},
+	))
+	if response.Code != http.StatusNoContent {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+}
@@ -0,0 +1,12 @@
+package controllers
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/responses"
+)
+
+func GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	responses.JSON(w, http.StatusOK, authentication.JWKS())
+}
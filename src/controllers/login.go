@@ -1,17 +1,28 @@
 package controllers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"log"
+	"math"
 	"net/http"
 	"social-network/src/authentication"
+	"social-network/src/config"
 	"social-network/src/database"
+	"social-network/src/lockout"
 	"social-network/src/models"
 	"social-network/src/repositories"
 	"social-network/src/responses"
 	"social-network/src/security"
+	"strconv"
+	"strings"
+	"time"
 )
 
+var errInvalidCredentials = errors.New("invalid identifier or password")
+
 func Login(w http.ResponseWriter, r *http.Request) {
 	request, error := ioutil.ReadAll(r.Body)
 	if error != nil {
@@ -19,8 +30,13 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
-	if error := json.Unmarshal(request, &user); error != nil {
+	var login models.Login
+	if error := json.Unmarshal(request, &login); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := login.Prepare(); error != nil {
 		responses.Error(w, http.StatusBadRequest, error)
 		return
 	}
@@ -33,22 +49,123 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.NewRepositoryUsers(db)
-	userDatabase, error := repository.GetUserForEmail(user.Email)
+	userDatabase, error := repository.GetUserForIdentifier(login.Identifier)
 	if error != nil {
 		responses.Error(w, http.StatusUnprocessableEntity, error)
 		return
 	}
 
-	if error := security.CheckPassword(userDatabase.Password, user.Password); error != nil {
-		responses.Error(w, http.StatusUnauthorized, error)
+	// Attempts are counted per account, so alternating between the nick and
+	// the e-mail of the same user doesn't give more tries.
+	accountKey := "account:" + strings.ToLower(login.Identifier)
+	if userDatabase.ID != 0 {
+		accountKey = "user:" + strconv.FormatUint(userDatabase.ID, 10)
+	}
+	store := newLoginAttemptsStore(db)
+	IPAddress := clientIP(r)
+	IPKey := "ip:" + IPAddress
+
+	retryAfter, error := lockout.Check(store, []string{accountKey, IPKey}, time.Now())
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if retryAfter > 0 {
+		tooManyAttempts(w, retryAfter)
+		return
+	}
+
+	if error := security.CheckPassword(userDatabase.Password, login.Password); error != nil {
+		now := time.Now()
+		if error := lockout.Fail(store, loginAccountPolicy(), accountKey, IPAddress, now); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		if error := lockout.Fail(store, loginIPPolicy(), IPKey, IPAddress, now); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		responses.Error(w, http.StatusUnauthorized, errInvalidCredentials)
+		return
+	}
+
+	if error := store.Reset(accountKey); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// The plain password is only known here, so hashes made with an older
+	// algorithm or parameters are upgraded now. A failure only delays the
+	// upgrade until the next login.
+	if security.NeedsRehash(userDatabase.Password) {
+		if passwordHash, error := security.Hash(login.Password); error != nil {
+			log.Printf("\nrehashing password of user %d: %v", userDatabase.ID, error)
+		} else if error := repository.UpdatePassword(userDatabase.ID, string(passwordHash)); error != nil {
+			log.Printf("\nrehashing password of user %d: %v", userDatabase.ID, error)
+		}
+	}
+
+	completeLogin(w, r, db, userDatabase.ID)
+}
+
+// completeLogin finishes a login whose first factor was already checked,
+// asking for the second one when the user has two-factor authentication.
+func completeLogin(w http.ResponseWriter, r *http.Request, db *sql.DB, userID uint64) {
+	twoFactor, error := repositories.NewRepositoryTwoFactor(db).Get(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
-	token, error := authentication.GenerateToken(userDatabase.ID)
+	if twoFactor.Enabled() {
+		challengeToken, error := authentication.GenerateChallengeToken(userID)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, models.TwoFactorChallenge{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
+		return
+	}
+
+	tokens, error := issueTokens(db, r, userID)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
-	w.Write([]byte(token))
+	responses.JSON(w, http.StatusOK, tokens)
+}
+
+var memoryLoginAttempts = lockout.NewMemoryStore()
+
+func newLoginAttemptsStore(db *sql.DB) lockout.Store {
+	if config.LoginAttemptsStore == "memory" {
+		return memoryLoginAttempts
+	}
+	return repositories.NewRepositoryLoginAttempts(db)
+}
+
+func loginAccountPolicy() lockout.Policy {
+	return lockout.Policy{
+		Threshold: config.LoginLockoutThreshold,
+		BaseDelay: config.LoginLockoutBaseDelay,
+		MaxDelay:  config.LoginLockoutMaxDelay,
+		Window:    config.LoginAttemptsWindow,
+	}
+}
+
+func loginIPPolicy() lockout.Policy {
+	policy := loginAccountPolicy()
+	policy.Threshold = config.LoginIPLockoutThreshold
+	return policy
+}
+
+func tooManyAttempts(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	responses.Error(w, http.StatusTooManyRequests, errors.New("too many failed login attempts, try again later"))
 }
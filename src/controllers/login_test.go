@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"social-network/src/security"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func login(identifier, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.Login{Identifier: identifier, Password: password})
+	response := httptest.NewRecorder()
+	Login(response, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(string(body))))
+	return response
+}
+
+// expectLookup expects the identifier to be looked up as an e-mail or a
+// nick, finding the user 9 with the password hash.
+func expectLookup(mock sqlmock.Sqlmock, column, identifier string, passwordHash []byte) {
+	mock.ExpectQuery("select id, password from users where " + column + " = ").
+		WithArgs(identifier).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(9, passwordHash))
+}
+
+func TestLoginWithNickOrEmail(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		column     string
+	}{
+		{"nick", "jane", "nick"},
+		{"e-mail", "jane@example.com", "email"},
+		{"padded", "  Jane@Example.com ", "email"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestSecret(t)
+			useCheapHasher(t)
+			useMemoryLoginAttempts(t)
+			passwordHash, error := security.Hash("correct horse battery staple")
+			if error != nil {
+				t.Fatal(error)
+			}
+
+			mock := expectConnection(t)
+			expectLookup(mock, test.column, strings.TrimSpace(test.identifier), passwordHash)
+			mock.ExpectQuery("from users where id = ").
+				WithArgs(9).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "totp_secret", "totp_enabled_at", "totp_last_step"}).AddRow(9, "", nil, 0))
+			mock.ExpectPrepare("insert into sessions").
+				ExpectExec().
+				WillReturnResult(sqlmock.NewResult(4, 1))
+			mock.ExpectQuery("select role from users").
+				WithArgs(9).
+				WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("user"))
+
+			response := login(test.identifier, "correct horse battery staple")
+			if response.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", response.Code, response.Body)
+			}
+			var tokens models.Tokens
+			if error := json.NewDecoder(response.Body).Decode(&tokens); error != nil {
+				t.Fatal(error)
+			}
+			if accessToken, error := authentication.ParseAccessToken(tokens.AccessToken); error != nil || accessToken.UserID != 9 {
+				t.Errorf("access token %+v, error %v", accessToken, error)
+			}
+		})
+	}
+}
+
+// Failures are counted per account, so switching between the nick and the
+// e-mail doesn't give more tries.
+func TestLoginCountsFailuresPerAccount(t *testing.T) {
+	useCheapHasher(t)
+	useMemoryLoginAttempts(t)
+	passwordHash, error := security.Hash("correct horse battery staple")
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	for _, identifier := range []string{"jane", "jane@example.com"} {
+		mock := expectConnection(t)
+		column := "nick"
+		if strings.Contains(identifier, "@") {
+			column = "email"
+		}
+		expectLookup(mock, column, identifier, passwordHash)
+
+		if response := login(identifier, "wrong password"); response.Code != http.StatusUnauthorized {
+			t.Fatalf("%s status = %d, want %d: %s", identifier, response.Code, http.StatusUnauthorized, response.Body)
+		}
+	}
+
+	expectLookup(expectConnection(t), "nick", "jane", passwordHash)
+	if response := login("jane", "correct horse battery staple"); response.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusTooManyRequests, response.Body)
+	}
+}
+
+func TestLoginUnknownIdentifier(t *testing.T) {
+	useMemoryLoginAttempts(t)
+
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, password from users where nick = ").
+		WithArgs("nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}))
+
+	if response := login("nobody", "correct horse battery staple"); response.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/mailer"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"strings"
+	"time"
+)
+
+func RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var magicLinkRequest models.MagicLinkRequest
+	if error := json.Unmarshal(request, &magicLinkRequest); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := magicLinkRequest.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	user, error := repositories.NewRepositoryUsers(db).GetUserForEmail(magicLinkRequest.Email)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// Same as the password reset, unknown addresses get the same answer.
+	if user.ID == 0 {
+		responses.JSON(w, http.StatusAccepted, nil)
+		return
+	}
+
+	linkID, error := security.RandomToken(32)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := repositories.NewRepositoryMagicLinks(db).Create(models.MagicLink{
+		UserID:     user.ID,
+		Email:      magicLinkRequest.Email,
+		LinkIDHash: security.HashToken(linkID),
+		ExpiresAt:  time.Now().Add(config.MagicLinkDuration),
+	}); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	token, error := authentication.GenerateMagicLinkToken(magicLinkRequest.Email, linkID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	message := mailer.Message{
+		To:      magicLinkRequest.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf(
+			"Use the link below to sign in. It can be used once and expires in %s.\n\n%s/auth/magic-link/callback?token=%s\n\n"+
+				"If you didn't ask to sign in, ignore this message.\n",
+			config.MagicLinkDuration,
+			config.AppURL,
+			url.QueryEscape(token),
+		),
+	}
+	go func() {
+		if error := mailer.New().Send(message); error != nil {
+			log.Printf("\nsending magic link e-mail: %v", error)
+		}
+	}()
+
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+func MagicLinkCallback(w http.ResponseWriter, r *http.Request) {
+	email, linkID, error := authentication.ValidateMagicLinkToken(r.URL.Query().Get("token"))
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryMagicLinks(db)
+	magicLink, error := repository.GetForLinkIDHash(security.HashToken(linkID))
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if !magicLink.Valid() || !strings.EqualFold(magicLink.Email, email) {
+		responses.Error(w, http.StatusUnauthorized, authentication.ErrInvalidMagicLink)
+		return
+	}
+
+	if used, error := repository.Use(magicLink.ID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if !used {
+		responses.Error(w, http.StatusUnauthorized, authentication.ErrInvalidMagicLink)
+		return
+	}
+
+	// The link only proves ownership of the address it was sent to, so it
+	// stops working once the account moves to another e-mail.
+	user, error := repositories.NewRepositoryUsers(db).GetUser(magicLink.UserID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if user.ID == 0 || !strings.EqualFold(user.Email, email) {
+		responses.Error(w, http.StatusUnauthorized, errors.New("the e-mail of this account has changed"))
+		return
+	}
+
+	completeLogin(w, r, db, user.ID)
+}
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var magicLinkPattern = regexp.MustCompile(`/auth/magic-link/callback\?token=(\S+)`)
+
+// useFileMailer writes the e-mails to a file of the test, returning its path.
+func useFileMailer(t *testing.T) string {
+	t.Helper()
+	mailDriver, mailFile, appURL := config.MailDriver, config.MailFile, config.AppURL
+	config.MailDriver = "file"
+	config.MailFile = filepath.Join(t.TempDir(), "mail.log")
+	config.AppURL = "https://app.example.com"
+	t.Cleanup(func() { config.MailDriver, config.MailFile, config.AppURL = mailDriver, mailFile, appURL })
+	return config.MailFile
+}
+
+// readMail waits for the e-mails sent in the background.
+func readMail(t *testing.T, path string) string {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if mail, error := ioutil.ReadFile(path); error == nil && len(mail) > 0 {
+			return string(mail)
+		}
+	}
+	t.Fatal("no e-mail sent")
+	return ""
+}
+
+// requestMagicLink asks for a link for the account 9 and returns the link
+// e-mailed to it, with the row saved for it.
+func requestMagicLink(t *testing.T, mailFile, email string) (string, models.MagicLink) {
+	t.Helper()
+	magicLink := models.MagicLink{ID: 1, UserID: 9, Email: email, ExpiresAt: time.Now().Add(config.MagicLinkDuration)}
+
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, password from users where email = ").
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(9, "hash"))
+	mock.ExpectPrepare("insert into magic_links").
+		ExpectExec().
+		WithArgs(9, email, capturedArgument{&magicLink.LinkIDHash}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	request := httptest.NewRequest(http.MethodPost, "/auth/magic-link", strings.NewReader(`{"email": "`+email+`"}`))
+	response := httptest.NewRecorder()
+	RequestMagicLink(response, request)
+	if response.Code != http.StatusAccepted {
+		t.Fatalf("request status = %d: %s", response.Code, response.Body)
+	}
+
+	mail := readMail(t, mailFile)
+	if !strings.Contains(mail, "To: "+email+"\r\n") {
+		t.Errorf("e-mail not sent to %s:\n%s", email, mail)
+	}
+	link := magicLinkPattern.FindStringSubmatch(mail)
+	if link == nil {
+		t.Fatalf("no link in the e-mail:\n%s", mail)
+	}
+	if !strings.Contains(mail, config.AppURL+link[0]) {
+		t.Errorf("link doesn't point to the app:\n%s", mail)
+	}
+
+	token, error := url.QueryUnescape(link[1])
+	if error != nil {
+		t.Fatal(error)
+	}
+	return token, magicLink
+}
+
+func expectMagicLink(mock sqlmock.Sqlmock, magicLink models.MagicLink) {
+	mock.ExpectQuery("from magic_links where link_id_hash = ").
+		WithArgs(magicLink.LinkIDHash).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email", "link_id_hash", "expires_at", "used_at"}).AddRow(
+			magicLink.ID, magicLink.UserID, magicLink.Email, magicLink.LinkIDHash, magicLink.ExpiresAt, magicLink.UsedAt,
+		))
+}
+
+func expectUseMagicLink(mock sqlmock.Sqlmock, ID uint64, used bool) {
+	var rowsAffected int64
+	if used {
+		rowsAffected = 1
+	}
+	mock.ExpectPrepare("update magic_links set used_at").
+		ExpectExec().
+		WithArgs(ID).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func expectUser(mock sqlmock.Sqlmock, ID uint64, email string) {
+	mock.ExpectQuery("select id, name, nick, email, role, created_at from users").
+		WithArgs(ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "nick", "email", "role", "created_at"}).
+			AddRow(ID, "Jane Doe", "jane", email, "user", time.Now()))
+}
+
+func magicLinkCallback(token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/auth/magic-link/callback?token="+url.QueryEscape(token), nil)
+	response := httptest.NewRecorder()
+	MagicLinkCallback(response, request)
+	return response
+}
+
+func TestMagicLinkCanOnlyBeUsedOnce(t *testing.T) {
+	useTestSecret(t)
+	token, magicLink := requestMagicLink(t, useFileMailer(t), "jane@example.com")
+
+	mock := expectConnection(t)
+	expectMagicLink(mock, magicLink)
+	expectUseMagicLink(mock, magicLink.ID, true)
+	expectUser(mock, 9, "jane@example.com")
+	mock.ExpectQuery("from users where id = ").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "totp_secret", "totp_enabled_at", "totp_last_step"}).AddRow(9, "", nil, 0))
+	mock.ExpectPrepare("insert into sessions").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectQuery("select role from users").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("user"))
+
+	if response := magicLinkCallback(token); response.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", response.Code, response.Body)
+	}
+
+	usedAt := time.Now()
+	magicLink.UsedAt = &usedAt
+	expectMagicLink(expectConnection(t), magicLink)
+
+	if response := magicLinkCallback(token); response.Code != http.StatusUnauthorized {
+		t.Fatalf("reused link status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+}
+
+// Two requests with the same link race to use it, and only one wins.
+func TestMagicLinkLosesConcurrentUse(t *testing.T) {
+	useTestSecret(t)
+	token, magicLink := requestMagicLink(t, useFileMailer(t), "jane@example.com")
+
+	mock := expectConnection(t)
+	expectMagicLink(mock, magicLink)
+	expectUseMagicLink(mock, magicLink.ID, false)
+
+	if response := magicLinkCallback(token); response.Code != http.StatusUnauthorized {
+		t.Fatalf("callback status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+}
+
+func TestMagicLinkIsBoundToRequestingEmail(t *testing.T) {
+	useTestSecret(t)
+	mailFile := useFileMailer(t)
+	token, magicLink := requestMagicLink(t, mailFile, "jane@example.com")
+
+	// The link ID can't be moved to a token for another address.
+	email, linkID, error := authentication.ValidateMagicLinkToken(token)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if email != "jane@example.com" {
+		t.Fatalf("token e-mail = %q", email)
+	}
+	forged, error := authentication.GenerateMagicLinkToken("mallory@example.com", linkID)
+	if error != nil {
+		t.Fatal(error)
+	}
+	expectMagicLink(expectConnection(t), magicLink)
+	if response := magicLinkCallback(forged); response.Code != http.StatusUnauthorized {
+		t.Fatalf("forged link status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+
+	// The account moved to another address after the link was sent.
+	mock := expectConnection(t)
+	expectMagicLink(mock, magicLink)
+	expectUseMagicLink(mock, magicLink.ID, true)
+	expectUser(mock, 9, "jane.doe@example.com")
+	if response := magicLinkCallback(token); response.Code != http.StatusUnauthorized {
+		t.Fatalf("changed e-mail status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+}
+
+func TestMagicLinkExpires(t *testing.T) {
+	useTestSecret(t)
+	mailFile := useFileMailer(t)
+
+	token, magicLink := requestMagicLink(t, mailFile, "jane@example.com")
+	magicLink.ExpiresAt = time.Now().Add(-time.Second)
+	expectMagicLink(expectConnection(t), magicLink)
+	if response := magicLinkCallback(token); response.Code != http.StatusUnauthorized {
+		t.Fatalf("expired link status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+
+	// An expired token is turned down before reaching the database.
+	duration := config.MagicLinkDuration
+	config.MagicLinkDuration = -time.Minute
+	t.Cleanup(func() { config.MagicLinkDuration = duration })
+
+	expired, error := authentication.GenerateMagicLinkToken("jane@example.com", "link")
+	if error != nil {
+		t.Fatal(error)
+	}
+	if response := magicLinkCallback(expired); response.Code != http.StatusUnauthorized {
+		t.Fatalf("expired token status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+}
+
+func TestMagicLinkIsNotSentToUnknownEmail(t *testing.T) {
+	mailFile := useFileMailer(t)
+
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, password from users where email = ").
+		WithArgs("nobody@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}))
+
+	request := httptest.NewRequest(http.MethodPost, "/auth/magic-link", strings.NewReader(`{"email": "nobody@example.com"}`))
+	response := httptest.NewRecorder()
+	RequestMagicLink(response, request)
+	if response.Code != http.StatusAccepted {
+		t.Fatalf("request status = %d, want %d: %s", response.Code, http.StatusAccepted, response.Body)
+	}
+	if _, error := os.Stat(mailFile); !os.IsNotExist(error) {
+		t.Error("e-mail sent to an unknown address")
+	}
+}
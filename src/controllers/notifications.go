@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/database"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"strconv"
+)
+
+// ListMentions returns the posts mentioning the user, the latest first.
+func ListMentions(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPosts(db)
+	posts, error := repository.GetMentioningPosts(userID, limit, offset)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+// ListNotifications returns the notifications of the user, only the unread
+// ones when unread=true.
+func ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	unreadOnly := false
+	if value := r.URL.Query().Get("unread"); value != "" {
+		if unreadOnly, error = strconv.ParseBool(value); error != nil {
+			responses.Error(w, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryNotifications(db)
+	notifications, error := repository.List(userID, unreadOnly, limit, offset)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, notifications)
+}
+
+func MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryNotifications(db)
+	if error := repository.MarkAllRead(userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
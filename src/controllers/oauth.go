@@ -0,0 +1,610 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/oidc"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var errInvalidClient = errors.New("invalid client")
+
+func CreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var client models.OAuthClient
+	if error := json.Unmarshal(request, &client); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := client.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := authentication.ValidateScopes(client.Scopes); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+	if error := requireHeldScopes(r, client.Scopes); error != nil {
+		responses.Error(w, http.StatusForbidden, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireFirstPartyToken(db, r); error != nil {
+		responses.Error(w, http.StatusForbidden, error)
+		return
+	}
+
+	client.OwnerID = userID
+	if client.ClientID, error = security.RandomToken(16); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if client.Confidential {
+		if client.ClientSecret, error = security.RandomToken(32); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		client.ClientSecretHash = security.HashToken(client.ClientSecret)
+	}
+
+	repository := repositories.NewRepositoryOAuthClients(db)
+	client.ID, error = repository.Create(client)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, client)
+}
+
+func ListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryOAuthClients(db)
+	clients, error := repository.ListForOwner(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, clients)
+}
+
+func DeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	ID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryOAuthClients(db)
+	deleted, error := repository.Delete(ID, userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if !deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("client not found"))
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// GetOAuthAuthorization describes what the client asks for, so the consent
+// screen can show it to the user before they approve it.
+func GetOAuthAuthorization(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	authorization := models.OAuthAuthorization{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireFirstPartyToken(db, r); error != nil {
+		responses.Error(w, http.StatusForbidden, error)
+		return
+	}
+
+	client, scopes, error := validateAuthorization(db, &authorization)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.OAuthConsent{
+		ClientID:    client.ClientID,
+		Name:        client.Name,
+		RedirectURI: authorization.RedirectURI,
+		Scopes:      scopes,
+	})
+}
+
+func AuthorizeOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var authorization models.OAuthAuthorization
+	if error := json.Unmarshal(request, &authorization); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireFirstPartyToken(db, r); error != nil {
+		responses.Error(w, http.StatusForbidden, error)
+		return
+	}
+
+	client, scopes, error := validateAuthorization(db, &authorization)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	params := url.Values{}
+	if authorization.State != "" {
+		params.Set("state", authorization.State)
+	}
+
+	if !authorization.Approved {
+		params.Set("error", "access_denied")
+		responses.JSON(w, http.StatusOK, models.OAuthRedirect{RedirectTo: withQuery(authorization.RedirectURI, params)})
+		return
+	}
+
+	code, error := security.RandomToken(32)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	repository := repositories.NewRepositoryOAuthCodes(db)
+	if error := repository.Create(models.OAuthAuthorizationCode{
+		CodeHash:            security.HashToken(code),
+		ClientID:            client.ClientID,
+		UserID:              userID,
+		RedirectURI:         authorization.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       authorization.CodeChallenge,
+		CodeChallengeMethod: authorization.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(config.OAuthCodeDuration),
+	}); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	params.Set("code", code)
+	responses.JSON(w, http.StatusOK, models.OAuthRedirect{RedirectTo: withQuery(authorization.RedirectURI, params)})
+}
+
+func OAuthToken(w http.ResponseWriter, r *http.Request) {
+	if error := r.ParseForm(); error != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", error.Error())
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	client, error := authenticateClient(db, r)
+	if error != nil {
+		if error == errInvalidClient {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			oauthError(w, http.StatusUnauthorized, "invalid_client", "")
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		exchangeAuthorizationCode(w, r, db, client)
+	case "refresh_token":
+		tokens, _, error := refreshSession(db, r.PostForm.Get("refresh_token"), client.ClientID)
+		if error != nil {
+			if error == authentication.ErrInvalidRefreshToken || error == errRefreshTokenReused {
+				oauthError(w, http.StatusBadRequest, "invalid_grant", error.Error())
+				return
+			}
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		responses.JSON(w, http.StatusOK, tokens)
+	default:
+		oauthError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+	}
+}
+
+func OAuthIntrospect(w http.ResponseWriter, r *http.Request) {
+	if error := r.ParseForm(); error != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", error.Error())
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	client, error := authenticateClient(db, r)
+	if error != nil {
+		if error == errInvalidClient {
+			oauthError(w, http.StatusUnauthorized, "invalid_client", "")
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	session, tokenType, expiresAt, error := findTokenSession(db, r.PostForm.Get("token"))
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// Clients only learn about the tokens issued to themselves.
+	if session.ID == 0 || !session.Active() || session.ClientID != client.ClientID {
+		responses.JSON(w, http.StatusOK, models.OAuthIntrospection{Active: false})
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.OAuthIntrospection{
+		Active:    true,
+		Scope:     strings.Join(session.Scopes, " "),
+		ClientID:  session.ClientID,
+		Subject:   strconv.FormatUint(session.UserID, 10),
+		TokenType: tokenType,
+		ExpiresAt: expiresAt.Unix(),
+	})
+}
+
+func OAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	if error := r.ParseForm(); error != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", error.Error())
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	client, error := authenticateClient(db, r)
+	if error != nil {
+		if error == errInvalidClient {
+			oauthError(w, http.StatusUnauthorized, "invalid_client", "")
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	session, _, _, error := findTokenSession(db, r.PostForm.Get("token"))
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if session.ID != 0 && session.ClientID == client.ClientID {
+		if error := repositories.NewRepositorySessions(db).Revoke(session.ID); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	// Unknown tokens are answered the same way, as RFC 7009 asks.
+	responses.JSON(w, http.StatusOK, nil)
+}
+
+func exchangeAuthorizationCode(w http.ResponseWriter, r *http.Request, db *sql.DB, client models.OAuthClient) {
+	repository := repositories.NewRepositoryOAuthCodes(db)
+	code, error := repository.GetForCodeHash(security.HashToken(r.PostForm.Get("code")))
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if code.ID == 0 || code.ClientID != client.ClientID || time.Now().After(code.ExpiresAt) {
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "invalid or expired code")
+		return
+	}
+
+	if code.UsedAt != nil {
+		// A code exchanged twice may have leaked, so the tokens issued
+		// from it are revoked.
+		if code.SessionID != 0 {
+			if error := repositories.NewRepositorySessions(db).Revoke(code.SessionID); error != nil {
+				responses.Error(w, http.StatusInternalServerError, error)
+				return
+			}
+		}
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "code already used")
+		return
+	}
+
+	if r.PostForm.Get("redirect_uri") != code.RedirectURI {
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "redirect_uri mismatch")
+		return
+	}
+
+	if code.CodeChallenge != "" {
+		challenge := oidc.CodeChallenge(r.PostForm.Get("code_verifier"))
+		if subtle.ConstantTimeCompare([]byte(challenge), []byte(code.CodeChallenge)) != 1 {
+			oauthError(w, http.StatusBadRequest, "invalid_grant", "invalid code_verifier")
+			return
+		}
+	}
+
+	if used, error := repository.Use(code.ID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if !used {
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "code already used")
+		return
+	}
+
+	tokens, sessionID, error := startSession(db, models.Session{
+		UserID:    code.UserID,
+		UserAgent: truncate(r.UserAgent(), 255),
+		IPAddress: clientIP(r),
+		Scopes:    code.Scopes,
+		ClientID:  client.ClientID,
+	})
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := repository.SetSession(code.ID, sessionID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, tokens)
+}
+
+// validateAuthorization checks the client, redirect uri, scopes and PKCE
+// parameters of an authorization request, returning the granted scopes.
+func validateAuthorization(db *sql.DB, authorization *models.OAuthAuthorization) (models.OAuthClient, []string, error) {
+	client, error := repositories.NewRepositoryOAuthClients(db).GetForClientID(authorization.ClientID)
+	if error != nil {
+		return models.OAuthClient{}, nil, error
+	}
+	if client.ID == 0 {
+		return models.OAuthClient{}, nil, errInvalidClient
+	}
+
+	if authorization.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		authorization.RedirectURI = client.RedirectURIs[0]
+	}
+	if !client.HasRedirectURI(authorization.RedirectURI) {
+		return models.OAuthClient{}, nil, errors.New("redirect_uri not registered for this client")
+	}
+
+	if authorization.ResponseType != "code" {
+		return models.OAuthClient{}, nil, errors.New("response_type must be code")
+	}
+
+	if authorization.CodeChallenge == "" && !client.Confidential {
+		return models.OAuthClient{}, nil, errors.New("code_challenge required for public clients")
+	}
+	if authorization.CodeChallenge != "" && authorization.CodeChallengeMethod != "S256" {
+		return models.OAuthClient{}, nil, errors.New("code_challenge_method must be S256")
+	}
+
+	scopes := strings.Fields(authorization.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !authentication.HasScope(client.Scopes, scope) {
+			return models.OAuthClient{}, nil, errors.New("scope not allowed for this client: " + scope)
+		}
+	}
+
+	return client, scopes, nil
+}
+
+func authenticateClient(db *sql.DB, r *http.Request) (models.OAuthClient, error) {
+	clientID, clientSecret, hasBasicAuth := r.BasicAuth()
+	if hasBasicAuth {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" {
+		return models.OAuthClient{}, errInvalidClient
+	}
+
+	client, error := repositories.NewRepositoryOAuthClients(db).GetForClientID(clientID)
+	if error != nil {
+		return models.OAuthClient{}, error
+	}
+	if client.ID == 0 {
+		return models.OAuthClient{}, errInvalidClient
+	}
+
+	if client.Confidential {
+		secretHash := security.HashToken(clientSecret)
+		if subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.ClientSecretHash)) != 1 {
+			return models.OAuthClient{}, errInvalidClient
+		}
+	}
+	return client, nil
+}
+
+// findTokenSession resolves an access or refresh token to its session.
+func findTokenSession(db *sql.DB, token string) (models.Session, string, time.Time, error) {
+	repository := repositories.NewRepositorySessions(db)
+
+	if accessToken, error := authentication.ParseAccessToken(token); error == nil {
+		session, error := repository.GetSession(accessToken.SessionID)
+		return session, "access_token", accessToken.ExpiresAt, error
+	}
+
+	sessionID, secret, error := authentication.ParseRefreshToken(token)
+	if error != nil {
+		return models.Session{}, "", time.Time{}, nil
+	}
+
+	session, error := repository.GetSession(sessionID)
+	if error != nil {
+		return models.Session{}, "", time.Time{}, error
+	}
+	if session.RefreshTokenHash != security.HashToken(secret) {
+		return models.Session{}, "", time.Time{}, nil
+	}
+	return session, "refresh_token", session.ExpiresAt, nil
+}
+
+// requireFirstPartyToken keeps delegated credentials, like personal access
+// tokens or tokens issued to other clients, from granting access to new apps
+// or minting new credentials.
+func requireFirstPartyToken(db *sql.DB, r *http.Request) error {
+	if authentication.IsPersonalAccessToken(r) {
+		return errors.New("personal access tokens can't grant access to others")
+	}
+
+	sessionID, error := authentication.GetSessionID(r)
+	if error != nil {
+		return error
+	}
+
+	session, error := repositories.NewRepositorySessions(db).GetSession(sessionID)
+	if error != nil {
+		return error
+	}
+	if session.ClientID != "" {
+		return errors.New("tokens issued to clients can't grant access to others")
+	}
+	return nil
+}
+
+// requireHeldScopes keeps credentials from being handed scopes the token
+// creating them doesn't hold.
+func requireHeldScopes(r *http.Request, scopes []string) error {
+	principal, error := authentication.GetPrincipal(r)
+	if error != nil {
+		return error
+	}
+	for _, scope := range scopes {
+		if !principal.HasScope(scope) {
+			return errors.New("scope " + scope + " isn't held by the current token")
+		}
+	}
+	return nil
+}
+
+func withQuery(address string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(address, "?") {
+		separator = "&"
+	}
+	return address + separator + params.Encode()
+}
+
+func oauthError(w http.ResponseWriter, statusCode int, code, description string) {
+	responses.JSON(w, statusCode, models.OAuthError{Error: code, ErrorDescription: description})
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"social-network/src/oidc"
+	"social-network/src/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const oauthRedirectURI = "https://client.example.com/callback"
+
+func expectOAuthClient(mock sqlmock.Sqlmock, clientID string) {
+	mock.ExpectQuery("from oauth_clients where client_id = ").
+		WithArgs(clientID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "client_id", "client_secret_hash", "name", "redirect_uris", "scopes", "owner_id", "created_at",
+		}).AddRow(1, clientID, "", "Client", oauthRedirectURI, "posts:read posts:write", 2, time.Now()))
+}
+
+func expectAuthorizationCode(mock sqlmock.Sqlmock, code, clientID, verifier string) {
+	mock.ExpectQuery("from oauth_authorization_codes where code_hash = ").
+		WithArgs(security.HashToken(code)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "code_hash", "client_id", "user_id", "redirect_uri", "scopes", "code_challenge",
+			"code_challenge_method", "session_id", "expires_at", "used_at",
+		}).AddRow(
+			5, security.HashToken(code), clientID, 7, oauthRedirectURI, "posts:read", oidc.CodeChallenge(verifier),
+			"S256", 0, time.Now().Add(time.Minute), nil,
+		))
+}
+
+func oauthToken(form url.Values) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+	OAuthToken(response, request)
+	return response
+}
+
+func decodeAccessToken(t *testing.T, response *httptest.ResponseRecorder) authentication.AccessToken {
+	t.Helper()
+	var tokens models.Tokens
+	if error := json.NewDecoder(response.Body).Decode(&tokens); error != nil {
+		t.Fatal(error)
+	}
+	accessToken, error := authentication.ParseAccessToken(tokens.AccessToken)
+	if error != nil {
+		t.Fatal(error)
+	}
+	return accessToken
+}
+
+// An admin authorizing a client hands it their account, not their powers
+// over the accounts of others.
+func TestOAuthTokenCapsClientRole(t *testing.T) {
+	useTestSecret(t)
+	mock := expectConnection(t)
+	expectOAuthClient(mock, "client")
+	expectAuthorizationCode(mock, "code", "client", "verifier")
+	mock.ExpectPrepare("update oauth_authorization_codes set used_at").
+		ExpectExec().
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("insert into sessions").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectPrepare("update oauth_authorization_codes set session_id").
+		ExpectExec().
+		WithArgs(4, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	response := oauthToken(url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"client"},
+		"code":          {"code"},
+		"redirect_uri":  {oauthRedirectURI},
+		"code_verifier": {"verifier"},
+	})
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+
+	accessToken := decodeAccessToken(t, response)
+	if accessToken.UserID != 7 || accessToken.SessionID != 4 {
+		t.Errorf("unexpected access token %+v", accessToken)
+	}
+	if accessToken.Role != models.RoleUser {
+		t.Errorf("role = %q, want %q", accessToken.Role, models.RoleUser)
+	}
+}
+
+func TestOAuthRefreshCapsClientRole(t *testing.T) {
+	useTestSecret(t)
+	mock := expectConnection(t)
+	expectOAuthClient(mock, "client")
+	expectSession(mock, 3, "secret", "client", time.Now().Add(time.Hour), nil)
+	mock.ExpectBegin()
+	mock.ExpectExec("update sessions set refresh_token_hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("insert into rotated_refresh_tokens").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	response := oauthToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {"client"},
+		"refresh_token": {authentication.FormatRefreshToken(3, "secret")},
+	})
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	if role := decodeAccessToken(t, response).Role; role != models.RoleUser {
+		t.Errorf("role = %q, want %q", role, models.RoleUser)
+	}
+}
+
+// Sessions the user started themselves keep the role of the account.
+func TestSessionTokensKeepUserRole(t *testing.T) {
+	useTestSecret(t)
+	db, mock := newMockDB(t)
+	mock.ExpectQuery("select role from users").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(models.RoleAdmin))
+
+	tokens, error := sessionTokens(db, models.Session{ID: 3, UserID: 7, Scopes: authentication.AllScopes}, "secret")
+	if error != nil {
+		t.Fatal(error)
+	}
+	accessToken, error := authentication.ParseAccessToken(tokens.AccessToken)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if accessToken.Role != models.RoleAdmin {
+		t.Errorf("role = %q, want %q", accessToken.Role, models.RoleAdmin)
+	}
+}
+
+func TestCreateOAuthClientRequiresFirstPartyToken(t *testing.T) {
+	body := `{"name": "Client", "redirect_uris": ["` + oauthRedirectURI + `"], "scopes": ["posts:read"]}`
+
+	t.Run("personal access token", func(t *testing.T) {
+		expectConnection(t)
+
+		response := httptest.NewRecorder()
+		CreateOAuthClient(response, authenticatedRequest(
+			http.MethodPost, "/oauth/clients", body,
+			authentication.Principal{UserID: 7, PersonalAccessTokenID: 2, Scopes: authentication.AllScopes, Role: "user"},
+			nil,
+		))
+		if response.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusForbidden, response.Body)
+		}
+	})
+
+	t.Run("client token", func(t *testing.T) {
+		expectSession(expectConnection(t), 3, "secret", "client", time.Now().Add(time.Hour), nil)
+
+		response := httptest.NewRecorder()
+		CreateOAuthClient(response, authenticatedRequest(
+			http.MethodPost, "/oauth/clients", body,
+			authentication.Principal{UserID: 7, SessionID: 3, Scopes: authentication.AllScopes, Role: "user"},
+			nil,
+		))
+		if response.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusForbidden, response.Body)
+		}
+	})
+
+	t.Run("scope not held", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		CreateOAuthClient(response, authenticatedRequest(
+			http.MethodPost, "/oauth/clients", body,
+			authentication.Principal{UserID: 7, SessionID: 3, Scopes: []string{"posts:write"}, Role: "user"},
+			nil,
+		))
+		if response.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusForbidden, response.Body)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/oidc"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errUnknownProvider = errors.New("unknown identity provider")
+	errInvalidState    = errors.New("invalid or expired state")
+	nickInvalidChars   = regexp.MustCompile(`[^a-z0-9_]`)
+)
+
+func OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	providerName := mux.Vars(r)["provider"]
+	provider, ok := config.OIDCProviders[providerName]
+	if !ok {
+		responses.Error(w, http.StatusNotFound, errUnknownProvider)
+		return
+	}
+
+	metadata, error := oidc.Discover(provider.Issuer)
+	if error != nil {
+		responses.Error(w, http.StatusBadGateway, error)
+		return
+	}
+
+	var state, nonce, verifier string
+	for _, value := range []*string{&state, &nonce, &verifier} {
+		if *value, error = security.RandomToken(32); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryOIDCStates(db)
+	if error := repository.Create(models.OIDCState{
+		StateHash:    security.HashToken(state),
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(config.OIDCStateDuration),
+	}); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	http.Redirect(w, r, oidc.AuthorizationURL(provider, metadata, state, nonce, verifier), http.StatusFound)
+}
+
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	providerName := mux.Vars(r)["provider"]
+	provider, ok := config.OIDCProviders[providerName]
+	if !ok {
+		responses.Error(w, http.StatusNotFound, errUnknownProvider)
+		return
+	}
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		responses.Error(w, http.StatusUnauthorized, fmt.Errorf("identity provider error: %s", providerError))
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	state, error := repositories.NewRepositoryOIDCStates(db).Consume(security.HashToken(query.Get("state")))
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if state.ID == 0 || state.Provider != providerName || time.Now().After(state.ExpiresAt) {
+		responses.Error(w, http.StatusBadRequest, errInvalidState)
+		return
+	}
+
+	metadata, error := oidc.Discover(provider.Issuer)
+	if error != nil {
+		responses.Error(w, http.StatusBadGateway, error)
+		return
+	}
+
+	idToken, error := oidc.Exchange(provider, metadata, query.Get("code"), state.CodeVerifier)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	claims, error := oidc.VerifyIDToken(provider, metadata, idToken, state.Nonce)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	userID, statusCode, error := resolveIdentity(db, providerName, claims)
+	if error != nil {
+		responses.Error(w, statusCode, error)
+		return
+	}
+
+	completeLogin(w, r, db, userID)
+}
+
+// resolveIdentity finds the user linked to the external identity, linking it
+// by verified e-mail to an existing account or creating a new one.
+func resolveIdentity(db *sql.DB, provider string, claims oidc.Claims) (uint64, int, error) {
+	repositoryIdentities := repositories.NewRepositoryUserIdentities(db)
+	userID, error := repositoryIdentities.GetUserID(provider, claims.Subject)
+	if error != nil {
+		return 0, http.StatusInternalServerError, error
+	}
+	if userID != 0 {
+		return userID, 0, nil
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return 0, http.StatusForbidden, errors.New("identity provider didn't return a verified e-mail")
+	}
+
+	repositoryUsers := repositories.NewRepositoryUsers(db)
+	user, error := repositoryUsers.GetUserForEmail(claims.Email)
+	if error != nil {
+		return 0, http.StatusInternalServerError, error
+	}
+
+	if user.ID != 0 {
+		emailStatus, error := repositoryUsers.GetEmailStatus(user.ID)
+		if error != nil {
+			return 0, http.StatusInternalServerError, error
+		}
+		// Linking to an unverified account would hand it to whoever
+		// registered the address first.
+		if emailStatus.EmailVerifiedAt == nil {
+			return 0, http.StatusConflict, errors.New("an account with this e-mail exists but it is not verified")
+		}
+	} else {
+		if user.ID, error = createExternalUser(db, claims); error != nil {
+			return 0, http.StatusInternalServerError, error
+		}
+	}
+
+	if error := repositoryIdentities.Create(models.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); error != nil {
+		return 0, http.StatusInternalServerError, error
+	}
+
+	return user.ID, 0, nil
+}
+
+func createExternalUser(db *sql.DB, claims oidc.Claims) (uint64, error) {
+	// The account has no usable password until the user resets it.
+	password, error := security.RandomToken(32)
+	if error != nil {
+		return 0, error
+	}
+
+	nick := nickInvalidChars.ReplaceAllString(strings.ToLower(strings.Split(claims.Email, "@")[0]), "")
+	if len(nick) > 40 {
+		nick = nick[:40]
+	}
+
+	user := models.User{
+		Name:     claims.Name,
+		Nick:     nick + "_" + security.HashToken(claims.Subject)[:6],
+		Email:    claims.Email,
+		Password: password,
+	}
+	if user.Name == "" {
+		user.Name = nick
+	}
+	// The column holds 50 characters, and cutting bytes could split one.
+	if name := []rune(user.Name); len(name) > 50 {
+		user.Name = string(name[:50])
+	}
+
+	if error := user.Prepare("create"); error != nil {
+		return 0, error
+	}
+
+	repository := repositories.NewRepositoryUsers(db)
+	ID, error := repository.Create(user)
+	if error != nil {
+		return 0, error
+	}
+
+	if error := repository.MarkEmailVerified(ID); error != nil {
+		return 0, error
+	}
+	return ID, nil
+}
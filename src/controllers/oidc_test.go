@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/models"
+	"social-network/src/oidc"
+	"social-network/src/oidc/oidctest"
+	"social-network/src/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+var oidcStateColumns = []string{"id", "state_hash", "provider", "code_verifier", "nonce", "expires_at"}
+
+func useOIDCIssuer(t *testing.T) *oidctest.Issuer {
+	t.Helper()
+	issuer := oidctest.NewIssuer(t, "app")
+
+	providers := config.OIDCProviders
+	config.OIDCProviders = map[string]config.OIDCProvider{
+		"mock": issuer.Provider("https://app.example.com/auth/oidc/mock/callback"),
+	}
+	t.Cleanup(func() { config.OIDCProviders = providers })
+	return issuer
+}
+
+// startOIDCLogin starts the login at the issuer and signs the user in there,
+// returning the callback the issuer sent them back to and the state saved
+// for it.
+func startOIDCLogin(t *testing.T, issuer *oidctest.Issuer) (*url.URL, models.OIDCState) {
+	t.Helper()
+	state := models.OIDCState{ID: 1, ExpiresAt: time.Now().Add(config.OIDCStateDuration)}
+
+	mock := expectConnection(t)
+	mock.ExpectPrepare("insert into oidc_states").
+		ExpectExec().
+		WithArgs(
+			capturedArgument{&state.StateHash},
+			"mock",
+			capturedArgument{&state.CodeVerifier},
+			capturedArgument{&state.Nonce},
+			sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	request := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/login", nil), map[string]string{"provider": "mock"})
+	response := httptest.NewRecorder()
+	OIDCLogin(response, request)
+	if response.Code != http.StatusFound {
+		t.Fatalf("login status = %d: %s", response.Code, response.Body)
+	}
+	state.Provider = "mock"
+
+	authorizationURL, error := url.Parse(response.Header().Get("Location"))
+	if error != nil {
+		t.Fatal(error)
+	}
+	query := authorizationURL.Query()
+	if security.HashToken(query.Get("state")) != state.StateHash {
+		t.Error("saved state isn't the hash of the one sent to the issuer")
+	}
+	if query.Get("nonce") != state.Nonce || query.Get("code_challenge") != oidc.CodeChallenge(state.CodeVerifier) {
+		t.Error("nonce or code challenge don't match the saved state")
+	}
+	if query.Get("code_verifier") != "" {
+		t.Error("verifier sent to the issuer")
+	}
+
+	callback, error := issuer.Authorize(authorizationURL.String())
+	if error != nil {
+		t.Fatal(error)
+	}
+	return callback, state
+}
+
+func expectConsumeState(mock sqlmock.Sqlmock, state models.OIDCState) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("from oidc_states").
+		WithArgs(state.StateHash).
+		WillReturnRows(sqlmock.NewRows(oidcStateColumns).AddRow(
+			state.ID, state.StateHash, state.Provider, state.CodeVerifier, state.Nonce, state.ExpiresAt,
+		))
+	mock.ExpectExec("delete from oidc_states").
+		WithArgs(state.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func expectIdentity(mock sqlmock.Sqlmock, subject string, userID uint64) {
+	rows := sqlmock.NewRows([]string{"user_id"})
+	if userID != 0 {
+		rows.AddRow(userID)
+	}
+	mock.ExpectQuery("select user_id from user_identities").
+		WithArgs("mock", subject).
+		WillReturnRows(rows)
+}
+
+func expectAccount(mock sqlmock.Sqlmock, email string, userID uint64, emailVerifiedAt *time.Time) {
+	mock.ExpectQuery("select id, password from users where email = ").
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(userID, "hash"))
+	mock.ExpectQuery("email_verified_at from users where id = ").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "pending_email", "email_verified_at"}).
+			AddRow(userID, email, "", emailVerifiedAt))
+}
+
+func callback(callbackURL *url.URL) *httptest.ResponseRecorder {
+	request := mux.SetURLVars(httptest.NewRequest(http.MethodGet, callbackURL.RequestURI(), nil), map[string]string{"provider": "mock"})
+	response := httptest.NewRecorder()
+	OIDCCallback(response, request)
+	return response
+}
+
+func TestOIDCLoginLinksVerifiedAccount(t *testing.T) {
+	useTestSecret(t)
+	issuer := useOIDCIssuer(t)
+	callbackURL, state := startOIDCLogin(t, issuer)
+
+	verifiedAt := time.Now().Add(-time.Hour)
+	mock := expectConnection(t)
+	expectConsumeState(mock, state)
+	expectIdentity(mock, issuer.Identity.Subject, 0)
+	expectAccount(mock, issuer.Identity.Email, 9, &verifiedAt)
+	mock.ExpectPrepare("insert into user_identities").
+		ExpectExec().
+		WithArgs(9, "mock", issuer.Identity.Subject, issuer.Identity.Email).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("from users where id = ").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "totp_secret", "totp_enabled_at", "totp_last_step"}).AddRow(9, "", nil, 0))
+	mock.ExpectPrepare("insert into sessions").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectQuery("select role from users").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("user"))
+
+	response := callback(callbackURL)
+	if response.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", response.Code, response.Body)
+	}
+
+	var tokens models.Tokens
+	if error := json.NewDecoder(response.Body).Decode(&tokens); error != nil {
+		t.Fatal(error)
+	}
+	accessToken, error := authentication.ParseAccessToken(tokens.AccessToken)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if accessToken.UserID != 9 || accessToken.SessionID != 4 {
+		t.Errorf("unexpected access token %+v", accessToken)
+	}
+}
+
+// The state is deleted when the callback reads it, so a replayed callback
+// finds none.
+func TestOIDCCallbackRejectsConsumedState(t *testing.T) {
+	issuer := useOIDCIssuer(t)
+	callbackURL, state := startOIDCLogin(t, issuer)
+
+	mock := expectConnection(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("from oidc_states").
+		WithArgs(state.StateHash).
+		WillReturnRows(sqlmock.NewRows(oidcStateColumns))
+	mock.ExpectRollback()
+
+	if response := callback(callbackURL); response.Code != http.StatusBadRequest {
+		t.Fatalf("callback status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+	}
+}
+
+func TestOIDCCallbackRejectsInvalidState(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(state *models.OIDCState)
+	}{
+		{"expired", func(state *models.OIDCState) { state.ExpiresAt = time.Now().Add(-time.Second) }},
+		{"other provider", func(state *models.OIDCState) { state.Provider = "other" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issuer := useOIDCIssuer(t)
+			callbackURL, state := startOIDCLogin(t, issuer)
+			test.change(&state)
+
+			expectConsumeState(expectConnection(t), state)
+
+			if response := callback(callbackURL); response.Code != http.StatusBadRequest {
+				t.Fatalf("callback status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+			}
+		})
+	}
+}
+
+// A code intercepted on its way back can't be exchanged without the verifier
+// kept with the state, and an ID token issued for another login is rejected.
+func TestOIDCCallbackRequiresVerifierAndNonce(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(issuer *oidctest.Issuer, state *models.OIDCState)
+	}{
+		{"other verifier", func(issuer *oidctest.Issuer, state *models.OIDCState) { state.CodeVerifier = "other verifier" }},
+		{"other nonce", func(issuer *oidctest.Issuer, state *models.OIDCState) { state.Nonce = "other nonce" }},
+		{"replayed id token", func(issuer *oidctest.Issuer, state *models.OIDCState) {
+			issuer.Claims = func(claims jwt.MapClaims) { claims["nonce"] = "nonce of another login" }
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issuer := useOIDCIssuer(t)
+			callbackURL, state := startOIDCLogin(t, issuer)
+
+			consumed := state
+			test.change(issuer, &consumed)
+			expectConsumeState(expectConnection(t), consumed)
+
+			if response := callback(callbackURL); response.Code != http.StatusUnauthorized {
+				t.Fatalf("callback status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+			}
+		})
+	}
+}
+
+func TestResolveIdentity(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+	claims := oidc.Claims{Subject: "subject", Email: "jane@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name       string
+		claims     oidc.Claims
+		expect     func(mock sqlmock.Sqlmock)
+		userID     uint64
+		statusCode int
+	}{
+		{
+			name:   "linked identity",
+			claims: oidc.Claims{Subject: "subject", Email: "changed@example.com"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectIdentity(mock, "subject", 9)
+			},
+			userID: 9,
+		},
+		{
+			name:   "unverified e-mail",
+			claims: oidc.Claims{Subject: "subject", Email: "jane@example.com"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectIdentity(mock, "subject", 0)
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:   "unverified account",
+			claims: claims,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectIdentity(mock, "subject", 0)
+				expectAccount(mock, "jane@example.com", 9, nil)
+			},
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:   "verified account",
+			claims: claims,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectIdentity(mock, "subject", 0)
+				expectAccount(mock, "jane@example.com", 9, &verifiedAt)
+				mock.ExpectPrepare("insert into user_identities").
+					ExpectExec().
+					WithArgs(9, "mock", "subject", "jane@example.com").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			userID: 9,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			test.expect(mock)
+
+			userID, statusCode, error := resolveIdentity(db, "mock", test.claims)
+			if test.statusCode != 0 {
+				if error == nil || statusCode != test.statusCode {
+					t.Fatalf("status = %d, error = %v, want %d", statusCode, error, test.statusCode)
+				}
+				return
+			}
+			if error != nil {
+				t.Fatal(error)
+			}
+			if userID != test.userID {
+				t.Errorf("user = %d, want %d", userID, test.userID)
+			}
+		})
+	}
+}
+
+// Names are cut to the 50 characters of the column without splitting any.
+func TestCreateExternalUserTruncatesName(t *testing.T) {
+	useCheapHasher(t)
+	db, mock := newMockDB(t)
+
+	var name string
+	mock.ExpectPrepare("insert into users").
+		ExpectExec().
+		WithArgs(capturedArgument{&name}, sqlmock.AnyArg(), "jane@example.com", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectPrepare("update users set email_verified_at").
+		ExpectExec().
+		WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	claims := oidc.Claims{Subject: "subject", Email: "jane@example.com", Name: strings.Repeat("é", 60)}
+	if _, error := createExternalUser(db, claims); error != nil {
+		t.Fatal(error)
+	}
+	if name != strings.Repeat("é", 50) {
+		t.Errorf("name = %q, want 50 characters", name)
+	}
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// getPagination reads the limit and offset query parameters of paginated
+// listings.
+func getPagination(r *http.Request) (uint64, uint64, error) {
+	query := r.URL.Query()
+
+	limit := uint64(defaultPageSize)
+	if value := query.Get("limit"); value != "" {
+		var error error
+		if limit, error = strconv.ParseUint(value, 10, 64); error != nil || limit == 0 || limit > maxPageSize {
+			return 0, 0, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+	}
+
+	var offset uint64
+	if value := query.Get("offset"); value != "" {
+		var error error
+		if offset, error = strconv.ParseUint(value, 10, 64); error != nil {
+			return 0, 0, errors.New("offset must be a positive number")
+		}
+	}
+
+	return limit, offset, nil
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/mailer"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"strings"
+	"time"
+)
+
+var errInvalidResetToken = errors.New("invalid or expired reset token")
+
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var forgotPassword models.ForgotPassword
+	if error := json.Unmarshal(request, &forgotPassword); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := forgotPassword.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	user, error := repositories.NewRepositoryUsers(db).GetUserForEmail(forgotPassword.Email)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// The response is the same whether the e-mail exists or not, so the
+	// endpoint can't be used to find out which addresses are registered.
+	if user.ID == 0 {
+		responses.JSON(w, http.StatusAccepted, nil)
+		return
+	}
+
+	token, error := security.RandomToken(32)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	repository := repositories.NewRepositoryPasswordResets(db)
+	if _, error := repository.Create(models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: security.HashToken(token),
+		ExpiresAt: time.Now().Add(config.PasswordResetDuration),
+	}); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	message := mailer.Message{
+		To:      forgotPassword.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Use the link below to choose a new password. It expires in %s.\n\n%s/reset-password?token=%s\n\n"+
+				"If you didn't ask for a password reset, ignore this message.\n",
+			config.PasswordResetDuration,
+			config.AppURL,
+			url.QueryEscape(token),
+		),
+	}
+	go func() {
+		if error := mailer.New().Send(message); error != nil {
+			log.Printf("\nsending password reset e-mail: %v", error)
+		}
+	}()
+
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var resetPassword models.ResetPassword
+	if error := json.Unmarshal(request, &resetPassword); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPasswordResets(db)
+	passwordReset, error := repository.GetForTokenHash(security.HashToken(strings.TrimSpace(resetPassword.Token)))
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if !passwordReset.Valid() {
+		responses.Error(w, http.StatusBadRequest, errInvalidResetToken)
+		return
+	}
+
+	// The policy needs the nick and e-mail of the owner, and is checked
+	// before the token is used so a rejected password doesn't waste it.
+	user, error := repositories.NewRepositoryUsers(db).GetUser(passwordReset.UserID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := resetPassword.Prepare(user); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if used, error := repository.Use(passwordReset.ID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if !used {
+		responses.Error(w, http.StatusBadRequest, errInvalidResetToken)
+		return
+	}
+
+	if error := repositories.NewRepositoryUsers(db).UpdatePassword(passwordReset.UserID, resetPassword.NewPassword); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := repository.InvalidateForUser(passwordReset.UserID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := repositories.NewRepositorySessions(db).RevokeAll(passwordReset.UserID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := repositories.NewRepositoryPersonalAccessTokens(db).RevokeAll(passwordReset.UserID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"social-network/src/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var resetLinkPattern = regexp.MustCompile(`/reset-password\?token=(\S+)`)
+
+// requestPasswordReset asks for a reset of the account 7 and returns the
+// token e-mailed to it.
+func requestPasswordReset(t *testing.T, mailFile string) string {
+	t.Helper()
+	var tokenHash string
+
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, password from users where email = ").
+		WithArgs("jane@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(7, "hash"))
+	mock.ExpectPrepare("insert into password_resets").
+		ExpectExec().
+		WithArgs(7, capturedArgument{&tokenHash}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	request := httptest.NewRequest(http.MethodPost, "/auth/forgot-password", strings.NewReader(`{"email": "jane@example.com"}`))
+	response := httptest.NewRecorder()
+	ForgotPassword(response, request)
+	if response.Code != http.StatusAccepted {
+		t.Fatalf("request status = %d: %s", response.Code, response.Body)
+	}
+
+	mail := readMail(t, mailFile)
+	link := resetLinkPattern.FindStringSubmatch(mail)
+	if link == nil {
+		t.Fatalf("no link in the e-mail:\n%s", mail)
+	}
+	token, error := url.QueryUnescape(link[1])
+	if error != nil {
+		t.Fatal(error)
+	}
+	if security.HashToken(token) != tokenHash {
+		t.Error("saved hash isn't the hash of the token e-mailed")
+	}
+	return token
+}
+
+func expectPasswordReset(mock sqlmock.Sqlmock, token string, expiresAt time.Time, usedAt *time.Time) {
+	mock.ExpectQuery("from password_resets where token_hash = ").
+		WithArgs(security.HashToken(token)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "used_at", "created_at"}).
+			AddRow(1, 7, security.HashToken(token), expiresAt, usedAt, time.Now()))
+}
+
+func resetPassword(token string) *httptest.ResponseRecorder {
+	body := `{"token": "` + token + `", "new_password": "correct horse battery staple"}`
+	request := httptest.NewRequest(http.MethodPost, "/auth/reset-password", strings.NewReader(body))
+	response := httptest.NewRecorder()
+	ResetPassword(response, request)
+	return response
+}
+
+// A reset signs the account out everywhere and revokes its personal access
+// tokens, which may be what leaked.
+func TestResetPasswordRevokesCredentials(t *testing.T) {
+	useCheapHasher(t)
+	token := requestPasswordReset(t, useFileMailer(t))
+
+	mock := expectConnection(t)
+	expectPasswordReset(mock, token, time.Now().Add(time.Hour), nil)
+	expectUser(mock, 7, "jane@example.com")
+	mock.ExpectPrepare("update password_resets set used_at").
+		ExpectExec().
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("update users set password").
+		ExpectExec().
+		WithArgs(sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("update password_resets set used_at").
+		ExpectExec().
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare("update sessions set revoked_at").
+		ExpectExec().
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectPrepare("update personal_access_tokens set revoked_at").
+		ExpectExec().
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if response := resetPassword(token); response.Code != http.StatusNoContent {
+		t.Fatalf("reset status = %d: %s", response.Code, response.Body)
+	}
+}
+
+func TestResetPasswordRejectsInvalidToken(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		usedAt    *time.Time
+	}{
+		{"expired", time.Now().Add(-time.Second), nil},
+		{"used", time.Now().Add(time.Hour), &usedAt},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expectPasswordReset(expectConnection(t), "token", test.expiresAt, test.usedAt)
+
+			if response := resetPassword("token"); response.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+			}
+		})
+	}
+}
+
+// Two requests with the same token race to use it, and only one wins.
+func TestResetPasswordLosesConcurrentUse(t *testing.T) {
+	useCheapHasher(t)
+
+	mock := expectConnection(t)
+	expectPasswordReset(mock, "token", time.Now().Add(time.Hour), nil)
+	expectUser(mock, 7, "jane@example.com")
+	mock.ExpectPrepare("update password_resets set used_at").
+		ExpectExec().
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if response := resetPassword("token"); response.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+	}
+}
+
+func TestForgotPasswordIsNotSentToUnknownEmail(t *testing.T) {
+	mailFile := useFileMailer(t)
+
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, password from users where email = ").
+		WithArgs("nobody@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}))
+
+	request := httptest.NewRequest(http.MethodPost, "/auth/forgot-password", strings.NewReader(`{"email": "nobody@example.com"}`))
+	response := httptest.NewRecorder()
+	ForgotPassword(response, request)
+	if response.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusAccepted, response.Body)
+	}
+	if _, error := os.Stat(mailFile); !os.IsNotExist(error) {
+		t.Error("e-mail sent to an unknown address")
+	}
+}
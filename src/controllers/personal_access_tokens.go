@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func CreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	if authentication.IsPersonalAccessToken(r) {
+		responses.Error(w, http.StatusForbidden, errors.New("personal access tokens can't create other tokens"))
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var token models.PersonalAccessToken
+	if error := json.Unmarshal(request, &token); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := token.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := authentication.ValidateScopes(token.Scopes); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+	if error := requireHeldScopes(r, token.Scopes); error != nil {
+		responses.Error(w, http.StatusForbidden, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireFirstPartyToken(db, r); error != nil {
+		responses.Error(w, http.StatusForbidden, error)
+		return
+	}
+
+	token.UserID = userID
+	token.Token, error = authentication.GeneratePersonalAccessToken()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	token.TokenHash = security.HashToken(token.Token)
+
+	repository := repositories.NewRepositoryPersonalAccessTokens(db)
+	token.ID, error = repository.Create(token)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, token)
+}
+
+func ListPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPersonalAccessTokens(db)
+	tokens, error := repository.List(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, tokens)
+}
+
+func RevokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	tokenID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPersonalAccessTokens(db)
+	revoked, error := repository.Revoke(tokenID, userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if !revoked {
+		responses.Error(w, http.StatusNotFound, errors.New("token not found"))
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
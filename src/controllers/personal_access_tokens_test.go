@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"social-network/src/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const personalAccessTokenBody = `{"name": "bot", "scopes": ["posts:read"]}`
+
+func createPersonalAccessToken(principal authentication.Principal) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	CreatePersonalAccessToken(response, authenticatedRequest(
+		http.MethodPost, "/tokens", personalAccessTokenBody, principal, nil,
+	))
+	return response
+}
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	var tokenHash string
+	mock := expectConnection(t)
+	expectSession(mock, 3, "secret", "", time.Now().Add(time.Hour), nil)
+	mock.ExpectPrepare("insert into personal_access_tokens").
+		ExpectExec().
+		WithArgs(7, "bot", capturedArgument{&tokenHash}, "posts:read", nil).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	response := createPersonalAccessToken(authentication.Principal{UserID: 7, SessionID: 3, Scopes: authentication.AllScopes, Role: "user"})
+	if response.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+
+	var token models.PersonalAccessToken
+	if error := json.NewDecoder(response.Body).Decode(&token); error != nil {
+		t.Fatal(error)
+	}
+	if token.ID != 2 || !strings.HasPrefix(token.Token, authentication.PersonalAccessTokenPrefix) {
+		t.Errorf("unexpected token %+v", token)
+	}
+	// Only the hash of the token is kept.
+	if tokenHash != security.HashToken(token.Token) {
+		t.Error("saved hash isn't the hash of the token returned")
+	}
+}
+
+// Delegated credentials can't mint new ones, nor hand out scopes they don't
+// hold themselves.
+func TestCreatePersonalAccessTokenRequiresFirstPartyToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal authentication.Principal
+		expect    func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:      "personal access token",
+			principal: authentication.Principal{UserID: 7, PersonalAccessTokenID: 2, Scopes: authentication.AllScopes, Role: "user"},
+		},
+		{
+			name:      "scope not held",
+			principal: authentication.Principal{UserID: 7, SessionID: 3, Scopes: []string{"posts:write"}, Role: "user"},
+		},
+		{
+			name:      "client token",
+			principal: authentication.Principal{UserID: 7, SessionID: 3, Scopes: authentication.AllScopes, Role: "user"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectSession(mock, 3, "secret", "client", time.Now().Add(time.Hour), nil)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.expect != nil {
+				test.expect(expectConnection(t))
+			}
+
+			if response := createPersonalAccessToken(test.principal); response.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusForbidden, response.Body)
+			}
+		})
+	}
+}
+
+// Revoking a token of someone else is answered as if it didn't exist.
+func TestRevokePersonalAccessTokenOfAnotherUser(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectPrepare("update personal_access_tokens set revoked_at").
+		ExpectExec().
+		WithArgs(2, 9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	response := httptest.NewRecorder()
+	RevokePersonalAccessToken(response, authenticatedRequest(
+		http.MethodDelete, "/tokens/2", "",
+		authentication.Principal{UserID: 9, SessionID: 3, Scopes: authentication.AllScopes, Role: "user"},
+		map[string]string{"id": "2"},
+	))
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
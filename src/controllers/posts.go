@@ -3,6 +3,7 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"social-network/src/authentication"
@@ -15,6 +16,16 @@ import (
 	"github.com/gorilla/mux"
 )
 
+var errPostNotFound = errors.New("post not found")
+
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+	// maxThreadReplies caps the replies of a whole thread, which would
+	// otherwise grow with the limit to the power of the depth.
+	maxThreadReplies = 500
+)
+
 func CreatePost(w http.ResponseWriter, r *http.Request) {
 	authorID, error := authentication.GetUserID(r)
 	if error != nil {
@@ -47,12 +58,58 @@ func CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 	defer db.Close()
 
+	if error := requireVerifiedEmail(db, authorID); error != nil {
+		if error == errEmailNotVerified {
+			responses.Error(w, http.StatusForbidden, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
 	repository := repositories.NewRepositoryPosts(db)
+	if post.InReplyToID != nil {
+		if parent, error := repository.GetPost(*post.InReplyToID, authorID); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		} else if parent.ID == 0 {
+			responses.Error(w, http.StatusUnprocessableEntity, errors.New("the post replied to doesn't exist"))
+			return
+		}
+	}
+
+	if post.QuotedPostID != nil {
+		quotedPost, error := repository.GetPost(*post.QuotedPostID, authorID)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		if quotedPost.ID == 0 {
+			responses.Error(w, http.StatusUnprocessableEntity, errors.New("the quoted post doesn't exist"))
+			return
+		}
+
+		if blocked, error := repositories.NewRepositoryUsers(db).IsBlocked(quotedPost.AuthorID, authorID); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		} else if blocked {
+			responses.Error(w, http.StatusForbidden, errBlocked)
+			return
+		}
+	}
+
 	post.ID, error = repository.Create(post)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
+
+	// Mentions are only resolved when stored.
+	post, error = repository.GetPost(post.ID, authorID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
 	responses.JSON(w, http.StatusCreated, post)
 }
 
@@ -63,14 +120,23 @@ func ListPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	includeReplies := true
+	if value := r.URL.Query().Get("include_replies"); value != "" {
+		if includeReplies, error = strconv.ParseBool(value); error != nil {
+			responses.Error(w, http.StatusBadRequest, errors.New("include_replies must be true or false"))
+			return
+		}
+	}
+
 	db, error := database.Connect()
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
+	defer db.Close()
 
 	repository := repositories.NewRepositoryPosts(db)
-	posts, error := repository.ListPosts(userID)
+	posts, error := repository.ListPosts(userID, includeReplies)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
@@ -79,6 +145,12 @@ func ListPosts(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetPost(w http.ResponseWriter, r *http.Request) {
+	viewerID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
 	params := mux.Vars(r)
 	postId, error := strconv.ParseUint(params["id"], 10, 64)
 	if error != nil {
@@ -94,7 +166,7 @@ func GetPost(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.NewRepositoryPosts(db)
-	post, error := repository.GetPost(postId)
+	post, error := repository.GetPost(postId, viewerID)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
@@ -102,6 +174,104 @@ func GetPost(w http.ResponseWriter, r *http.Request) {
 	responses.JSON(w, http.StatusOK, post)
 }
 
+// GetThread returns the posts a post replies to and a page of the replies
+// below it, each with its replies down to the requested depth.
+func GetThread(w http.ResponseWriter, r *http.Request) {
+	viewerID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	depth := defaultThreadDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		if depth, error = strconv.Atoi(value); error != nil || depth < 1 || depth > maxThreadDepth {
+			responses.Error(w, http.StatusBadRequest, fmt.Errorf("depth must be between 1 and %d", maxThreadDepth))
+			return
+		}
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPosts(db)
+	post, error := repository.GetPost(postID, viewerID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	ancestors, error := repository.GetAncestors(postID, viewerID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// Replies are loaded one level at a time: the first level is paginated
+	// and the deeper ones bring at most limit replies of each post, until
+	// the thread has maxThreadReplies replies.
+	replies := map[uint64][]models.Post{}
+	parentIDs := []uint64{postID}
+	remaining := uint64(maxThreadReplies)
+	for level := 0; level < depth && len(parentIDs) > 0 && remaining > 0; level++ {
+		levelOffset := uint64(0)
+		if level == 0 {
+			levelOffset = offset
+		}
+
+		levelReplies, error := repository.GetReplies(parentIDs, limit, levelOffset, remaining, viewerID)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		remaining -= uint64(len(levelReplies))
+		parentIDs = nil
+		for _, reply := range levelReplies {
+			replies[*reply.InReplyToID] = append(replies[*reply.InReplyToID], reply)
+			parentIDs = append(parentIDs, reply.ID)
+		}
+	}
+
+	responses.JSON(w, http.StatusOK, models.Thread{
+		Ancestors: ancestors,
+		Post:      post,
+		Replies:   buildThreadReplies(replies, postID),
+	})
+}
+
+func buildThreadReplies(replies map[uint64][]models.Post, parentID uint64) []models.ThreadReply {
+	threadReplies := []models.ThreadReply{}
+	for _, reply := range replies[parentID] {
+		threadReplies = append(threadReplies, models.ThreadReply{
+			Post:    reply,
+			Replies: buildThreadReplies(replies, reply.ID),
+		})
+	}
+	return threadReplies
+}
+
 func UpdatePost(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	postID, error := strconv.ParseUint(params["id"], 10, 64)
@@ -123,11 +293,26 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 	defer db.Close()
 
+	if error := requireVerifiedEmail(db, authorID); error != nil {
+		if error == errEmailNotVerified {
+			responses.Error(w, http.StatusForbidden, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
 	repository := repositories.NewRepositoryPosts(db)
-	if post, error := repository.GetPost(postID); error != nil {
+	actualPost, error := repository.GetPost(postID, authorID)
+	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
-	} else if post.AuthorID != authorID {
+	}
+	if actualPost.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+	if actualPost.AuthorID != authorID {
 		responses.Error(w, http.StatusForbidden, errors.New("it's only allowed to update a post of your authorship"))
 		return
 	}
@@ -144,6 +329,8 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	post.AuthorID = authorID
+	post.InReplyToID = actualPost.InReplyToID
+	post.QuotedPostID = actualPost.QuotedPostID
 
 	if error := post.Prepare(); error != nil {
 		responses.Error(w, http.StatusBadRequest, error)
@@ -180,10 +367,13 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.NewRepositoryPosts(db)
-	if post, error := repository.GetPost(postID); error != nil {
+	if post, error := repository.GetPost(postID, authorID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
-	} else if post.AuthorID != authorID {
+	} else if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	} else if post.AuthorID != authorID && !authentication.IsModerator(r) {
 		responses.Error(w, http.StatusForbidden, errors.New("it's only allowed to delete a post of your authorship"))
 		return
 	}
@@ -196,6 +386,12 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetPostsPerUser(w http.ResponseWriter, r *http.Request) {
+	viewerID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
 	params := mux.Vars(r)
 	userId, error := strconv.ParseUint(params["userId"], 10, 64)
 	if error != nil {
@@ -211,7 +407,7 @@ func GetPostsPerUser(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.NewRepositoryPosts(db)
-	posts, error := repository.GetPostsPerUser(userId)
+	posts, error := repository.GetPostsPerUser(userId, viewerID)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
@@ -219,7 +415,14 @@ func GetPostsPerUser(w http.ResponseWriter, r *http.Request) {
 	responses.JSON(w, http.StatusOK, posts)
 }
 
+// LikePost is idempotent, a user likes a post at most once.
 func LikePost(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
 	params := mux.Vars(r)
 	postID, error := strconv.ParseUint(params["id"], 10, 64)
 	if error != nil {
@@ -233,15 +436,39 @@ func LikePost(w http.ResponseWriter, r *http.Request) {
 	}
 	defer db.Close()
 
+	if error := requireVerifiedEmail(db, userID); error != nil {
+		if error == errEmailNotVerified {
+			responses.Error(w, http.StatusForbidden, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
 	repository := repositories.NewRepositoryPosts(db)
-	if error = repository.LikePost(postID); error != nil {
+	if post, error := repository.GetPost(postID, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	if error = repository.LikePost(postID, userID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
+// UnlikePost only takes back a like the user gave, doing nothing otherwise.
 func UnlikePost(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
 	params := mux.Vars(r)
 	postID, error := strconv.ParseUint(params["id"], 10, 64)
 	if error != nil {
@@ -257,9 +484,170 @@ func UnlikePost(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.NewRepositoryPosts(db)
-	if error = repository.UnlikePost(postID); error != nil {
+	if error = repository.UnlikePost(postID, userID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 	responses.JSON(w, http.StatusNoContent, nil)
 }
+
+// Repost shares the post with the followers of the user, unless its author
+// blocked them. Reposting again changes nothing.
+func Repost(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireVerifiedEmail(db, userID); error != nil {
+		if error == errEmailNotVerified {
+			responses.Error(w, http.StatusForbidden, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	repository := repositories.NewRepositoryPosts(db)
+	post, error := repository.GetPost(postID, userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	if blocked, error := repositories.NewRepositoryUsers(db).IsBlocked(post.AuthorID, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if blocked {
+		responses.Error(w, http.StatusForbidden, errBlocked)
+		return
+	}
+
+	if error := repository.Repost(postID, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func Unrepost(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPosts(db)
+	if error := repository.Unrepost(postID, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetPostLikes(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPosts(db)
+	if post, error := repository.GetPost(postID, 0); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	users, error := repository.GetLikers(postID, limit, offset)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, users)
+}
+
+func GetUserLikes(w http.ResponseWriter, r *http.Request) {
+	viewerID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID, error := strconv.ParseUint(params["userId"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPosts(db)
+	posts, error := repository.GetLikedPosts(userID, limit, offset, viewerID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, posts)
+}
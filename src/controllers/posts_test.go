@@ -0,0 +1,449 @@
+package controllers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var postColumns = []string{
+	"id", "title", "content", "author_id", "nick", "in_reply_to_id", "quoted_post_id", "likes",
+	"comments_count", "replies_count", "reposts_count", "created_at",
+}
+
+// postRows returns posts of the user 7 with the given IDs, replying to
+// inReplyToID when it isn't zero.
+func postRows(inReplyToID uint64, IDs ...uint64) *sqlmock.Rows {
+	rows := sqlmock.NewRows(postColumns)
+	for _, ID := range IDs {
+		var parentID driver.Value
+		if inReplyToID != 0 {
+			parentID = inReplyToID
+		}
+		rows.AddRow(ID, "", "post", 7, "jane", parentID, nil, 0, 0, 0, 0, time.Now())
+	}
+	return rows
+}
+
+// expectPostDetails expects the queries completing a page of posts, which
+// find no tags, mentions, reactions or likes of the viewer.
+func expectPostDetails(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("from post_tags").WillReturnRows(sqlmock.NewRows([]string{"post_id", "name"}))
+	mock.ExpectQuery("from post_mentions").WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+	mock.ExpectQuery("from post_reactions").WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+	mock.ExpectQuery("from post_likes").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func expectPost(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectQuery("where p.id = ").WillReturnRows(rows)
+	expectPostDetails(mock)
+}
+
+func expectReplies(mock sqlmock.Sqlmock, args []driver.Value, rows *sqlmock.Rows, found bool) {
+	mock.ExpectQuery("partition by in_reply_to_id").WithArgs(args...).WillReturnRows(rows)
+	if found {
+		expectPostDetails(mock)
+	}
+}
+
+func getThread(target string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	GetThread(response, authenticatedRequest(
+		http.MethodGet, target, "",
+		authentication.Principal{UserID: 9, Role: "user"},
+		map[string]string{"id": "1"},
+	))
+	return response
+}
+
+func TestGetThread(t *testing.T) {
+	mock := expectConnection(t)
+	expectPost(mock, postRows(0, 1))
+	mock.ExpectQuery("with recursive ancestors").WithArgs(1).WillReturnRows(postRows(0))
+	expectReplies(mock, []driver.Value{1, 0, 2, maxThreadReplies}, postRows(1, 2, 3), true)
+	expectReplies(mock, []driver.Value{2, 3, 0, 2, maxThreadReplies - 2}, postRows(2, 4), true)
+
+	response := getThread("/posts/1/thread?limit=2&depth=2")
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+
+	var thread models.Thread
+	if error := json.NewDecoder(response.Body).Decode(&thread); error != nil {
+		t.Fatal(error)
+	}
+	if thread.Post.ID != 1 || len(thread.Replies) != 2 || len(thread.Replies[0].Replies) != 1 || thread.Replies[0].Replies[0].ID != 4 {
+		t.Errorf("unexpected thread %+v", thread)
+	}
+}
+
+// However deep the thread is asked, it stops growing at maxThreadReplies.
+func TestGetThreadCapsTotalReplies(t *testing.T) {
+	firstLevel := make([]uint64, 0, maxPageSize)
+	for ID := uint64(2); ID < 2+maxPageSize; ID++ {
+		firstLevel = append(firstLevel, ID)
+	}
+	secondLevel := make([]uint64, 0, maxThreadReplies-maxPageSize)
+	for index := 0; index < maxThreadReplies-maxPageSize; index++ {
+		secondLevel = append(secondLevel, uint64(1000+index))
+	}
+
+	mock := expectConnection(t)
+	expectPost(mock, postRows(0, 1))
+	mock.ExpectQuery("with recursive ancestors").WithArgs(1).WillReturnRows(postRows(0))
+	expectReplies(mock, []driver.Value{1, 0, maxPageSize, maxThreadReplies}, postRows(1, firstLevel...), true)
+
+	args := []driver.Value{}
+	for _, ID := range firstLevel {
+		args = append(args, ID)
+	}
+	args = append(args, 0, maxPageSize, maxThreadReplies-maxPageSize)
+	expectReplies(mock, args, postRows(2, secondLevel...), true)
+
+	if response := getThread("/posts/1/thread?limit=100&depth=10"); response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+}
+
+func TestGetThreadNotFound(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(postRows(0))
+
+	if response := getThread("/posts/1/thread"); response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
+
+func TestGetThreadRejectsDepth(t *testing.T) {
+	for _, depth := range []string{"0", "11", "x"} {
+		if response := getThread("/posts/1/thread?depth=" + depth); response.Code != http.StatusBadRequest {
+			t.Errorf("depth %s status = %d, want %d", depth, response.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func deletePost(principal authentication.Principal) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	DeletePost(response, authenticatedRequest(
+		http.MethodDelete, "/posts/1", "", principal, map[string]string{"id": "1"},
+	))
+	return response
+}
+
+func TestDeletePost(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  authentication.Principal
+		found      bool
+		deleted    bool
+		statusCode int
+	}{
+		{"author", authentication.Principal{UserID: 7, Role: "user"}, true, true, http.StatusNoContent},
+		{"another user", authentication.Principal{UserID: 9, Role: "user"}, true, false, http.StatusForbidden},
+		{"moderator", authentication.Principal{UserID: 9, Role: "moderator"}, true, true, http.StatusNoContent},
+		{"admin", authentication.Principal{UserID: 9, Role: "admin"}, true, true, http.StatusNoContent},
+		{"moderator on missing post", authentication.Principal{UserID: 9, Role: "moderator"}, false, false, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			if test.found {
+				expectPost(mock, postRows(0, 1))
+			} else {
+				mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+			}
+			if test.deleted {
+				mock.ExpectPrepare("delete from posts").
+					ExpectExec().
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			if response := deletePost(test.principal); response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
+
+func TestUpdatePostNotFound(t *testing.T) {
+	mock := expectConnection(t)
+	expectEmailStatus(mock, 7, true)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+
+	response := httptest.NewRecorder()
+	UpdatePost(response, authenticatedRequest(
+		http.MethodPut, "/posts/1", `{"title": "Title", "content": "post"}`,
+		authentication.Principal{UserID: 7, Role: "user"},
+		map[string]string{"id": "1"},
+	))
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
+
+func likeRequest(method string, userID uint64) *http.Request {
+	return authenticatedRequest(method, "/posts/1/like", "", authentication.Principal{UserID: userID, Role: "user"}, map[string]string{"id": "1"})
+}
+
+// Liking again or taking back a like never given leaves the counter alone.
+func TestLikesAreIdempotent(t *testing.T) {
+	t.Run("like again", func(t *testing.T) {
+		mock := expectConnection(t)
+		expectEmailStatus(mock, 9, true)
+		expectPost(mock, postRows(0, 1))
+		mock.ExpectBegin()
+		mock.ExpectExec("insert ignore into post_likes").WithArgs(9, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		response := httptest.NewRecorder()
+		LikePost(response, likeRequest(http.MethodPost, 9))
+		if response.Code != http.StatusNoContent {
+			t.Fatalf("status = %d: %s", response.Code, response.Body)
+		}
+	})
+
+	t.Run("unlike", func(t *testing.T) {
+		mock := expectConnection(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("delete from post_likes").WithArgs(9, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("update posts set likes = likes - 1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		response := httptest.NewRecorder()
+		UnlikePost(response, likeRequest(http.MethodDelete, 9))
+		if response.Code != http.StatusNoContent {
+			t.Fatalf("status = %d: %s", response.Code, response.Body)
+		}
+	})
+
+	t.Run("unlike without like", func(t *testing.T) {
+		mock := expectConnection(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("delete from post_likes").WithArgs(9, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		response := httptest.NewRecorder()
+		UnlikePost(response, likeRequest(http.MethodDelete, 9))
+		if response.Code != http.StatusNoContent {
+			t.Fatalf("status = %d: %s", response.Code, response.Body)
+		}
+	})
+}
+
+func TestLikeMissingPost(t *testing.T) {
+	mock := expectConnection(t)
+	expectEmailStatus(mock, 9, true)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+
+	response := httptest.NewRecorder()
+	LikePost(response, likeRequest(http.MethodPost, 9))
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
+
+func TestGetPostShowsLikedByMe(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(postRows(0, 1))
+	mock.ExpectQuery("from post_tags").WillReturnRows(sqlmock.NewRows([]string{"post_id", "name"}))
+	mock.ExpectQuery("from post_mentions").WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+	mock.ExpectQuery("from post_reactions").WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+	mock.ExpectQuery("from post_likes").
+		WithArgs(9, 9, 9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "liked", "reposted", "bookmarked"}).AddRow(1, true, false, false))
+
+	response := httptest.NewRecorder()
+	GetPost(response, likeRequest(http.MethodGet, 9))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var post models.Post
+	if error := json.NewDecoder(response.Body).Decode(&post); error != nil {
+		t.Fatal(error)
+	}
+	if !post.LikedByMe || post.RepostedByMe || post.BookmarkedByMe {
+		t.Errorf("unexpected viewer state %+v", post)
+	}
+}
+
+func TestGetPostLikes(t *testing.T) {
+	mock := expectConnection(t)
+	expectPost(mock, postRows(0, 1))
+	mock.ExpectQuery("from post_likes l").
+		WithArgs(1, 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "nick", "created_at"}).
+			AddRow(9, "John Doe", "john", time.Now()).
+			AddRow(8, "Mary Doe", "mary", time.Now()))
+
+	response := httptest.NewRecorder()
+	GetPostLikes(response, authenticatedRequest(
+		http.MethodGet, "/posts/1/likes?limit=2", "",
+		authentication.Principal{UserID: 9, Role: "user"},
+		map[string]string{"id": "1"},
+	))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var users []models.User
+	if error := json.NewDecoder(response.Body).Decode(&users); error != nil {
+		t.Fatal(error)
+	}
+	if len(users) != 2 || users[0].Nick != "john" {
+		t.Errorf("unexpected likers %+v", users)
+	}
+}
+
+func repostRequest(method string) *http.Request {
+	return authenticatedRequest(method, "/posts/1/repost", "", authentication.Principal{UserID: 9, Role: "user"}, map[string]string{"id": "1"})
+}
+
+func expectBlocked(mock sqlmock.Sqlmock, blockerID, blockedID uint64, blocked bool) {
+	rows := sqlmock.NewRows([]string{"blocked"})
+	if blocked {
+		rows.AddRow(1)
+	}
+	mock.ExpectQuery("from user_blocks where blocker_id = ").WithArgs(blockerID, blockedID).WillReturnRows(rows)
+}
+
+func TestRepost(t *testing.T) {
+	tests := []struct {
+		name       string
+		found      bool
+		blocked    bool
+		statusCode int
+	}{
+		{"repost", true, false, http.StatusNoContent},
+		{"blocked by the author", true, true, http.StatusForbidden},
+		{"missing post", false, false, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			expectEmailStatus(mock, 9, true)
+			if !test.found {
+				mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+			} else {
+				expectPost(mock, postRows(0, 1))
+				expectBlocked(mock, 7, 9, test.blocked)
+			}
+			if test.statusCode == http.StatusNoContent {
+				mock.ExpectPrepare("insert ignore into reposts").
+					ExpectExec().
+					WithArgs(9, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			response := httptest.NewRecorder()
+			Repost(response, repostRequest(http.MethodPost))
+			if response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
+
+func TestUnrepost(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectPrepare("delete from reposts").
+		ExpectExec().
+		WithArgs(9, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	response := httptest.NewRecorder()
+	Unrepost(response, repostRequest(http.MethodDelete))
+	if response.Code != http.StatusNoContent {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+}
+
+// The timeline attributes reposts to whoever reposted them.
+func TestListPostsShowsReposter(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("with timeline").
+		WithArgs(9, 9, true, 9, 9).
+		WillReturnRows(sqlmock.NewRows(append(postColumns, "reposter_id", "reposter_nick")).
+			AddRow(2, "", "post", 9, "john", nil, nil, 0, 0, 0, 0, time.Now(), nil, nil).
+			AddRow(1, "", "post", 7, "jane", nil, nil, 0, 0, 0, 1, time.Now(), 8, "mary"))
+	expectPostDetails(mock)
+
+	response := httptest.NewRecorder()
+	ListPosts(response, authenticatedRequest(http.MethodGet, "/posts", "", authentication.Principal{UserID: 9, Role: "user"}, nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var posts []models.Post
+	if error := json.NewDecoder(response.Body).Decode(&posts); error != nil {
+		t.Fatal(error)
+	}
+	if len(posts) != 2 || posts[0].RepostedBy != nil || posts[1].RepostedBy == nil || posts[1].RepostedBy.Nick != "mary" {
+		t.Errorf("unexpected posts %+v", posts)
+	}
+}
+
+func TestCreateQuotePost(t *testing.T) {
+	tests := []struct {
+		name       string
+		found      bool
+		blocked    bool
+		statusCode int
+	}{
+		{"missing quoted post", false, false, http.StatusUnprocessableEntity},
+		{"blocked by the author", true, true, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := expectConnection(t)
+			expectEmailStatus(mock, 9, true)
+			if !test.found {
+				mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+			} else {
+				expectPost(mock, postRows(0, 1))
+				expectBlocked(mock, 7, 9, test.blocked)
+			}
+
+			response := httptest.NewRecorder()
+			CreatePost(response, authenticatedRequest(
+				http.MethodPost, "/posts", `{"title": "Title", "content": "quote", "quoted_post_id": 1}`,
+				authentication.Principal{UserID: 9, Role: "user"},
+				nil,
+			))
+			if response.Code != test.statusCode {
+				t.Fatalf("status = %d, want %d: %s", response.Code, test.statusCode, response.Body)
+			}
+		})
+	}
+}
+
+// A quote whose original was deleted, or whose author blocked the quoting
+// user, keeps only the ID of the original.
+func TestGetQuotePostWithoutOriginal(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("where p.id = ").
+		WillReturnRows(sqlmock.NewRows(postColumns).AddRow(2, "", "quote", 9, "john", nil, 1, 0, 0, 0, 0, time.Now()))
+	expectPostDetails(mock)
+	mock.ExpectQuery("from posts q").WithArgs(2).WillReturnRows(sqlmock.NewRows(append(postColumns, "quoting_id")))
+
+	response := httptest.NewRecorder()
+	GetPost(response, authenticatedRequest(http.MethodGet, "/posts/2", "", authentication.Principal{UserID: 9, Role: "user"}, map[string]string{"id": "2"}))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var post models.Post
+	if error := json.NewDecoder(response.Body).Decode(&post); error != nil {
+		t.Fatal(error)
+	}
+	if post.QuotedPostID == nil || *post.QuotedPostID != 1 || post.QuotedPost != nil {
+		t.Errorf("unexpected quote %+v", post)
+	}
+}
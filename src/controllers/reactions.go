@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func AddReaction(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := models.ValidateReactionKind(params["kind"]); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := requireVerifiedEmail(db, userID); error != nil {
+		if error == errEmailNotVerified {
+			responses.Error(w, http.StatusForbidden, error)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if post, error := repositories.NewRepositoryPosts(db).GetPost(postID, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	if error := repositories.NewRepositoryReactions(db).Add(postID, userID, params["kind"]); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if error := repositories.NewRepositoryReactions(db).Remove(postID, userID, params["kind"]); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// ListReactions returns who reacted to a post, optionally filtered by the
+// kind query parameter.
+func ListReactions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	postID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind != "" {
+		if error := models.ValidateReactionKind(kind); error != nil {
+			responses.Error(w, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	if post, error := repositories.NewRepositoryPosts(db).GetPost(postID, 0); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errPostNotFound)
+		return
+	}
+
+	reactions, error := repositories.NewRepositoryReactions(db).ListReactors(postID, kind, limit, offset)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, reactions)
+}
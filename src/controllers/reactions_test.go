@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func reactionRequest(method, target, kind string) *http.Request {
+	return authenticatedRequest(method, target, "", authentication.Principal{UserID: 9, Role: "user"},
+		map[string]string{"id": "1", "kind": kind})
+}
+
+func TestAddReaction(t *testing.T) {
+	mock := expectConnection(t)
+	expectEmailStatus(mock, 9, true)
+	expectPost(mock, postRows(0, 1))
+	mock.ExpectPrepare("insert ignore into post_reactions").
+		ExpectExec().
+		WithArgs(1, 9, "heart").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	response := httptest.NewRecorder()
+	AddReaction(response, reactionRequest(http.MethodPut, "/posts/1/reactions/heart", "heart"))
+	if response.Code != http.StatusNoContent {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+}
+
+// Kinds outside the configured set are turned down before the database.
+func TestAddReactionRejectsUnknownKind(t *testing.T) {
+	response := httptest.NewRecorder()
+	AddReaction(response, reactionRequest(http.MethodPut, "/posts/1/reactions/poop", "poop"))
+	if response.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+	}
+}
+
+func TestAddReactionToMissingPost(t *testing.T) {
+	mock := expectConnection(t)
+	expectEmailStatus(mock, 9, true)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(sqlmock.NewRows(postColumns))
+
+	response := httptest.NewRecorder()
+	AddReaction(response, reactionRequest(http.MethodPut, "/posts/1/reactions/heart", "heart"))
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
+
+func TestRemoveReaction(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectPrepare("delete from post_reactions").
+		ExpectExec().
+		WithArgs(1, 9, "heart").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	response := httptest.NewRecorder()
+	RemoveReaction(response, reactionRequest(http.MethodDelete, "/posts/1/reactions/heart", "heart"))
+	if response.Code != http.StatusNoContent {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+}
+
+func TestListReactionsByKind(t *testing.T) {
+	mock := expectConnection(t)
+	expectPost(mock, postRows(0, 1))
+	mock.ExpectQuery("from post_reactions r").
+		WithArgs(1, "laugh", "laugh", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "id", "name", "nick", "created_at"}).
+			AddRow("laugh", 8, "Mary Doe", "mary", time.Now()))
+
+	response := httptest.NewRecorder()
+	ListReactions(response, reactionRequest(http.MethodGet, "/posts/1/reactions?kind=laugh&limit=20", ""))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var reactions []models.Reaction
+	if error := json.NewDecoder(response.Body).Decode(&reactions); error != nil {
+		t.Fatal(error)
+	}
+	if len(reactions) != 1 || reactions[0].Kind != "laugh" || reactions[0].User.Nick != "mary" {
+		t.Errorf("unexpected reactions %+v", reactions)
+	}
+
+	response = httptest.NewRecorder()
+	ListReactions(response, reactionRequest(http.MethodGet, "/posts/1/reactions?kind=poop", ""))
+	if response.Code != http.StatusBadRequest {
+		t.Fatalf("unknown kind status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+	}
+}
+
+// Posts embed the count of each kind and the kinds the viewer used.
+func TestGetPostShowsReactions(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("where p.id = ").WillReturnRows(postRows(0, 1))
+	mock.ExpectQuery("from post_tags").WillReturnRows(sqlmock.NewRows([]string{"post_id", "name"}))
+	mock.ExpectQuery("from post_mentions").WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+	mock.ExpectQuery("from post_reactions").
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "kind", "count", "mine"}).
+			AddRow(1, "heart", 3, 1).
+			AddRow(1, "laugh", 2, 0))
+	mock.ExpectQuery("from post_likes").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	response := httptest.NewRecorder()
+	GetPost(response, reactionRequest(http.MethodGet, "/posts/1", ""))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	var post models.Post
+	if error := json.NewDecoder(response.Body).Decode(&post); error != nil {
+		t.Fatal(error)
+	}
+	if post.Reactions["heart"] != 3 || post.Reactions["laugh"] != 2 || len(post.MyReactions) != 1 || post.MyReactions[0] != "heart" {
+		t.Errorf("reactions = %v, mine = %v", post.Reactions, post.MyReactions)
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/models"
+	"social-network/src/security"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListSessionsMarksCurrent(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("from sessions").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "user_agent", "ip_address", "client_id", "expires_at", "created_at"}).
+			AddRow(4, 7, "phone", "192.0.2.4", "", time.Now().Add(time.Hour), time.Now()).
+			AddRow(3, 7, "laptop", "192.0.2.3", "", time.Now().Add(time.Hour), time.Now().Add(-time.Hour)))
+
+	response := httptest.NewRecorder()
+	ListSessions(response, authenticatedRequest(
+		http.MethodGet, "/sessions", "",
+		authentication.Principal{UserID: 7, SessionID: 3, Role: "user"},
+		nil,
+	))
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+
+	var sessions []models.Session
+	if error := json.NewDecoder(response.Body).Decode(&sessions); error != nil {
+		t.Fatal(error)
+	}
+	if len(sessions) != 2 || sessions[0].Current || !sessions[1].Current {
+		t.Errorf("unexpected sessions %+v", sessions)
+	}
+}
+
+// Sessions of other users are answered as if they didn't exist.
+func TestRevokeSessionOfAnotherUser(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectPrepare("update sessions set revoked_at").
+		ExpectExec().
+		WithArgs(4, 9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	response := httptest.NewRecorder()
+	RevokeSession(response, authenticatedRequest(
+		http.MethodDelete, "/sessions/4", "",
+		authentication.Principal{UserID: 9, SessionID: 5, Role: "user"},
+		map[string]string{"id": "4"},
+	))
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNotFound, response.Body)
+	}
+}
+
+// A new password signs out every other session unless asked not to, and
+// always revokes the personal access tokens.
+func TestUpdatePasswordRevokesCredentials(t *testing.T) {
+	tests := []struct {
+		name           string
+		revokeSessions string
+		revoked        bool
+	}{
+		{"by default", "", true},
+		{"asked to", `, "revoke_other_sessions": true`, true},
+		{"asked not to", `, "revoke_other_sessions": false`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useCheapHasher(t)
+			passwordHash, error := security.Hash("old password")
+			if error != nil {
+				t.Fatal(error)
+			}
+
+			mock := expectConnection(t)
+			expectUser(mock, 7, "jane@example.com")
+			mock.ExpectQuery("select password from users").
+				WithArgs(7).
+				WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(passwordHash))
+			mock.ExpectPrepare("update users set password").
+				ExpectExec().
+				WithArgs(sqlmock.AnyArg(), 7).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectPrepare("update personal_access_tokens set revoked_at").
+				ExpectExec().
+				WithArgs(7).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			if test.revoked {
+				mock.ExpectPrepare("update sessions set revoked_at").
+					ExpectExec().
+					WithArgs(7, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			response := httptest.NewRecorder()
+			UpdatePassword(response, authenticatedRequest(
+				http.MethodPost, "/users/update-password",
+				`{"actual_password": "old password", "new_password": "correct horse battery staple"`+test.revokeSessions+`}`,
+				authentication.Principal{UserID: 7, SessionID: 3, Role: "user"},
+				nil,
+			))
+			if response.Code != http.StatusNoContent {
+				t.Fatalf("status = %d: %s", response.Code, response.Body)
+			}
+		})
+	}
+}
+
+func TestUpdatePasswordRejectsWrongPassword(t *testing.T) {
+	useCheapHasher(t)
+	passwordHash, error := security.Hash("old password")
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	mock := expectConnection(t)
+	expectUser(mock, 7, "jane@example.com")
+	mock.ExpectQuery("select password from users").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(passwordHash))
+
+	response := httptest.NewRecorder()
+	UpdatePassword(response, authenticatedRequest(
+		http.MethodPost, "/users/update-password",
+		`{"actual_password": "wrong password", "new_password": "correct horse battery staple"}`,
+		authentication.Principal{UserID: 7, SessionID: 3, Role: "user"},
+		nil,
+	))
+	if response.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+}
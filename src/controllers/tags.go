@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTagPosts lists the posts with the tag, which may be given with or
+// without its #, in any case.
+func GetTagPosts(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	tag, error := models.NormalizeTag(params["tag"])
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	limit, offset, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryPosts(db)
+	posts, error := repository.GetPostsForTag(tag, limit, offset, userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+// ListTrendingTags returns the trending tags of one of the configured
+// windows, given as a duration like 24h, by default the first one.
+func ListTrendingTags(w http.ResponseWriter, r *http.Request) {
+	window := config.TrendingWindows[0]
+	if value := r.URL.Query().Get("window"); value != "" {
+		duration, error := time.ParseDuration(value)
+		if error != nil || !isTrendingWindow(duration) {
+			responses.Error(w, http.StatusBadRequest, errors.New("unknown trending window"))
+			return
+		}
+		window = duration
+	}
+
+	limit, _, error := getPagination(r)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryTags(db)
+	tags, error := repository.ListTrending(window, limit)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	responses.JSON(w, http.StatusOK, tags)
+}
+
+func isTrendingWindow(duration time.Duration) bool {
+	for _, window := range config.TrendingWindows {
+		if duration == window {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/lockout"
+	"social-network/src/models"
+	"social-network/src/repositories"
+	"social-network/src/responses"
+	"social-network/src/security"
+	"social-network/src/totp"
+	"strconv"
+	"time"
+)
+
+const recoveryCodesCount = 10
+
+var errInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+func SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryTwoFactor(db)
+	twoFactor, error := repository.Get(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if twoFactor.Enabled() {
+		responses.Error(w, http.StatusConflict, errors.New("two-factor authentication already enabled"))
+		return
+	}
+
+	user, error := repositories.NewRepositoryUsers(db).GetUser(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	secret, error := totp.GenerateSecret()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := repository.SetPendingSecret(userID, secret); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.URI(config.TOTPIssuer, user.Email, secret),
+	})
+}
+
+func ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var code models.TwoFactorCode
+	if error := json.Unmarshal(request, &code); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := code.Prepare(false); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryTwoFactor(db)
+	twoFactor, error := repository.Get(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if twoFactor.Enabled() {
+		responses.Error(w, http.StatusConflict, errors.New("two-factor authentication already enabled"))
+		return
+	}
+	if twoFactor.Secret == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("two-factor setup not started"))
+		return
+	}
+
+	step, valid := totp.Validate(twoFactor.Secret, code.Code, time.Now())
+	if !valid {
+		responses.Error(w, http.StatusUnauthorized, errInvalidTwoFactorCode)
+		return
+	}
+
+	var recoveryCodes models.RecoveryCodes
+	var recoveryCodeHashes []string
+	for i := 0; i < recoveryCodesCount; i++ {
+		recoveryCode, error := security.RandomToken(8)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		recoveryCodes.RecoveryCodes = append(recoveryCodes.RecoveryCodes, recoveryCode)
+		recoveryCodeHashes = append(recoveryCodeHashes, security.HashToken(recoveryCode))
+	}
+
+	if error := repository.Enable(userID, step, recoveryCodeHashes); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, recoveryCodes)
+}
+
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var code models.TwoFactorCode
+	if error := json.Unmarshal(request, &code); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := code.Prepare(true); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	passwordHash, error := repositories.NewRepositoryUsers(db).GetPassword(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := security.CheckPassword(passwordHash, code.Password); error != nil {
+		responses.Error(w, http.StatusUnauthorized, errors.New("password is invalid"))
+		return
+	}
+
+	repository := repositories.NewRepositoryTwoFactor(db)
+	twoFactor, error := repository.Get(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if !twoFactor.Enabled() {
+		responses.Error(w, http.StatusConflict, errors.New("two-factor authentication not enabled"))
+		return
+	}
+
+	if valid, error := verifySecondFactor(db, twoFactor, code.Code); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if !valid {
+		responses.Error(w, http.StatusUnauthorized, errInvalidTwoFactorCode)
+		return
+	}
+
+	if error := repository.Disable(userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var challenge models.TwoFactorChallenge
+	if error := json.Unmarshal(request, &challenge); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	userID, challengeID, error := authentication.ValidateChallengeToken(challenge.ChallengeToken)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	code := models.TwoFactorCode{Code: challenge.Code}
+	if error := code.Prepare(false); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	// Wrong codes lock the account out like wrong passwords, but on a key of
+	// their own: the password step resets the account key, which would let
+	// whoever knows the password keep guessing codes at the base delay. A
+	// challenge also stops being accepted after a few wrong codes.
+	store := newLoginAttemptsStore(db)
+	accountKey := "user:" + strconv.FormatUint(userID, 10)
+	twoFactorKey := "2fa-user:" + strconv.FormatUint(userID, 10)
+	challengeKey := "2fa-challenge:" + challengeID
+	IPAddress := clientIP(r)
+
+	retryAfter, error := lockout.Check(store, []string{accountKey, twoFactorKey}, time.Now())
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if retryAfter > 0 {
+		tooManyAttempts(w, retryAfter)
+		return
+	}
+
+	challengeAttempts, error := store.Get(challengeKey)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if challengeAttempts.Failures >= config.TwoFactorMaxAttempts {
+		responses.Error(w, http.StatusUnauthorized, authentication.ErrInvalidChallengeToken)
+		return
+	}
+
+	repository := repositories.NewRepositoryTwoFactor(db)
+	twoFactor, error := repository.Get(userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	if !twoFactor.Enabled() {
+		responses.Error(w, http.StatusUnauthorized, authentication.ErrInvalidChallengeToken)
+		return
+	}
+
+	if valid, error := verifySecondFactor(db, twoFactor, code.Code); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if !valid {
+		now := time.Now()
+		if error := lockout.Fail(store, loginAccountPolicy(), twoFactorKey, IPAddress, now); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		if error := lockout.Fail(store, challengePolicy(), challengeKey, IPAddress, now); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+		responses.Error(w, http.StatusUnauthorized, errInvalidTwoFactorCode)
+		return
+	}
+
+	if error := store.Reset(twoFactorKey); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	tokens, error := issueTokens(db, r, userID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, tokens)
+}
+
+// verifySecondFactor accepts either a TOTP code that was not used before or
+// one of the unused recovery codes, consuming it in both cases.
+func verifySecondFactor(db *sql.DB, twoFactor models.TwoFactor, code string) (bool, error) {
+	repository := repositories.NewRepositoryTwoFactor(db)
+	if step, valid := totp.Validate(twoFactor.Secret, code, time.Now()); valid {
+		return repository.UseStep(twoFactor.UserID, step)
+	}
+	return repository.UseRecoveryCode(twoFactor.UserID, security.HashToken(code))
+}
+
+// challengePolicy only counts the failures of a challenge, without locking
+// it, as LoginTwoFactor refuses it past the maximum. They are kept at least
+// as long as login failures, so pruning the memory store for a challenge
+// doesn't forget login failures early.
+func challengePolicy() lockout.Policy {
+	window := config.ChallengeTokenDuration
+	if config.LoginAttemptsWindow > window {
+		window = config.LoginAttemptsWindow
+	}
+	return lockout.Policy{Window: window}
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/lockout"
+	"social-network/src/models"
+	"social-network/src/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// useMemoryLoginAttempts counts login failures in a fresh memory store,
+// locking accounts out after two of them.
+func useMemoryLoginAttempts(t *testing.T) {
+	t.Helper()
+	store, attempts := config.LoginAttemptsStore, memoryLoginAttempts
+	threshold, maxAttempts := config.LoginLockoutThreshold, config.TwoFactorMaxAttempts
+	config.LoginAttemptsStore, memoryLoginAttempts = "memory", lockout.NewMemoryStore()
+	config.LoginLockoutThreshold, config.TwoFactorMaxAttempts = 2, 5
+	t.Cleanup(func() {
+		config.LoginAttemptsStore, memoryLoginAttempts = store, attempts
+		config.LoginLockoutThreshold, config.TwoFactorMaxAttempts = threshold, maxAttempts
+	})
+}
+
+func expectTwoFactor(mock sqlmock.Sqlmock, userID uint64) {
+	enabledAt := time.Now().Add(-time.Hour)
+	mock.ExpectQuery("from users where id = ").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "totp_secret", "totp_enabled_at", "totp_last_step"}).
+			AddRow(userID, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", enabledAt, 0))
+}
+
+// expectRecoveryCode expects the code, which isn't a TOTP code, to be tried
+// as a recovery code.
+func expectRecoveryCode(mock sqlmock.Sqlmock, userID uint64, code string, used bool) {
+	var rowsAffected int64
+	if used {
+		rowsAffected = 1
+	}
+	mock.ExpectPrepare("update recovery_codes set used_at").
+		ExpectExec().
+		WithArgs(userID, security.HashToken(code)).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func loginTwoFactor(challengeToken, code string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.TwoFactorChallenge{ChallengeToken: challengeToken, Code: code})
+	response := httptest.NewRecorder()
+	LoginTwoFactor(response, httptest.NewRequest(http.MethodPost, "/login/2fa", strings.NewReader(string(body))))
+	return response
+}
+
+func failTwoFactor(t *testing.T, challengeToken string, want int) {
+	t.Helper()
+	mock := expectConnection(t)
+	if want == http.StatusUnauthorized {
+		expectTwoFactor(mock, 9)
+		expectRecoveryCode(mock, 9, "wrong-code", false)
+	}
+	if response := loginTwoFactor(challengeToken, "wrong-code"); response.Code != want {
+		t.Fatalf("status = %d, want %d: %s", response.Code, want, response.Body)
+	}
+}
+
+// Knowing the password mustn't reset the count of wrong codes, otherwise the
+// backoff never grows for whoever has the password.
+func TestLoginTwoFactorLockoutSurvivesPasswordLogin(t *testing.T) {
+	useTestSecret(t)
+	useCheapHasher(t)
+	useMemoryLoginAttempts(t)
+
+	challengeToken, error := authentication.GenerateChallengeToken(9)
+	if error != nil {
+		t.Fatal(error)
+	}
+	failTwoFactor(t, challengeToken, http.StatusUnauthorized)
+	failTwoFactor(t, challengeToken, http.StatusUnauthorized)
+	failTwoFactor(t, challengeToken, http.StatusTooManyRequests)
+
+	passwordHash, error := security.Hash("correct horse battery staple")
+	if error != nil {
+		t.Fatal(error)
+	}
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, password from users where nick = ").
+		WithArgs("jane").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(9, passwordHash))
+	expectTwoFactor(mock, 9)
+
+	response := httptest.NewRecorder()
+	Login(response, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"identifier": "jane", "password": "correct horse battery staple"}`)))
+	if response.Code != http.StatusOK {
+		t.Fatalf("login status = %d: %s", response.Code, response.Body)
+	}
+	var challenge models.TwoFactorChallenge
+	if error := json.NewDecoder(response.Body).Decode(&challenge); error != nil {
+		t.Fatal(error)
+	}
+	if !challenge.TwoFactorRequired {
+		t.Fatal("second factor not asked")
+	}
+
+	failTwoFactor(t, challenge.ChallengeToken, http.StatusTooManyRequests)
+}
+
+func TestLoginTwoFactorResetsFailuresOnSuccess(t *testing.T) {
+	useTestSecret(t)
+	useMemoryLoginAttempts(t)
+
+	challengeToken, error := authentication.GenerateChallengeToken(9)
+	if error != nil {
+		t.Fatal(error)
+	}
+	failTwoFactor(t, challengeToken, http.StatusUnauthorized)
+
+	mock := expectConnection(t)
+	expectTwoFactor(mock, 9)
+	expectRecoveryCode(mock, 9, "recovery-code", true)
+	mock.ExpectPrepare("insert into sessions").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectQuery("select role from users").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("user"))
+
+	if response := loginTwoFactor(challengeToken, "recovery-code"); response.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", response.Code, response.Body)
+	}
+	if attempts, _ := memoryLoginAttempts.Get("2fa-user:9"); attempts.Failures != 0 {
+		t.Errorf("failures = %d after a valid code", attempts.Failures)
+	}
+}
+
+func TestLoginTwoFactorLimitsAttemptsPerChallenge(t *testing.T) {
+	useTestSecret(t)
+	useMemoryLoginAttempts(t)
+	config.LoginLockoutThreshold, config.TwoFactorMaxAttempts = 0, 2
+
+	challengeToken, error := authentication.GenerateChallengeToken(9)
+	if error != nil {
+		t.Fatal(error)
+	}
+	failTwoFactor(t, challengeToken, http.StatusUnauthorized)
+	failTwoFactor(t, challengeToken, http.StatusUnauthorized)
+
+	// Even the right code is refused once the challenge is spent.
+	expectConnection(t)
+	if response := loginTwoFactor(challengeToken, "recovery-code"); response.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusUnauthorized, response.Body)
+	}
+
+	other, error := authentication.GenerateChallengeToken(9)
+	if error != nil {
+		t.Fatal(error)
+	}
+	failTwoFactor(t, other, http.StatusUnauthorized)
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/gorilla/mux"
 )
 
+var errBlocked = errors.New("you were blocked by this user")
+
 func CreateUser(w http.ResponseWriter, r *http.Request) {
 	request, error := ioutil.ReadAll(r.Body)
 	if error != nil {
@@ -46,6 +48,12 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 	user.ID, error = repository.Create(user)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error := sendEmailVerification(db, user.ID, user.Email); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
 	}
 
 	responses.JSON(w, http.StatusCreated, user)
@@ -110,7 +118,7 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if userIdToken != ID {
+	if userIdToken != ID && !authentication.IsAdmin(r) {
 		responses.Error(w, http.StatusForbidden, errors.New("update forbidden for this user"))
 		return
 	}
@@ -140,11 +148,51 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.NewRepositoryUsers(db)
+	actualUser, error := repository.GetUser(ID)
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if user.Nick != actualUser.Nick {
+		if error := user.ValidateNick(); error != nil {
+			responses.Error(w, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	// A new e-mail only replaces the current one after it is confirmed.
+	newEmail := ""
+	if !strings.EqualFold(user.Email, actualUser.Email) {
+		newEmail = user.Email
+		user.Email = actualUser.Email
+
+		if taken, error := repository.IsEmailTaken(newEmail, ID); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		} else if taken {
+			responses.Error(w, http.StatusConflict, errEmailTaken)
+			return
+		}
+	}
+
 	if error := repository.UpdateUser(ID, user); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
+	if newEmail != "" {
+		if error := repository.SetPendingEmail(ID, newEmail); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		if error := sendEmailVerification(db, ID, newEmail); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+	}
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -162,7 +210,7 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if userIdToken != ID {
+	if userIdToken != ID && !authentication.IsAdmin(r) {
 		responses.Error(w, http.StatusForbidden, errors.New("delete forbidden for this user"))
 		return
 	}
@@ -210,6 +258,14 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.NewRepositoryUsers(db)
+	if blocked, error := repository.IsBlocked(userId, followerId); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if blocked {
+		responses.Error(w, http.StatusForbidden, errBlocked)
+		return
+	}
+
 	if error := repository.FollowUser(userId, followerId); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
@@ -218,6 +274,79 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
+func BlockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	blockedID, error := strconv.ParseUint(params["userId"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if blockerID == blockedID {
+		responses.Error(w, http.StatusForbidden, errors.New("not allowed to block yourself"))
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryUsers(db)
+	if user, error := repository.GetUser(blockedID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if user.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	}
+
+	if error := repository.BlockUser(blockerID, blockedID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func UnblockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, error := authentication.GetUserID(r)
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	params := mux.Vars(r)
+	blockedID, error := strconv.ParseUint(params["userId"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryUsers(db)
+	if error := repository.UnblockUser(blockerID, blockedID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
 func UnfollowUser(w http.ResponseWriter, r *http.Request) {
 	followerId, error := authentication.GetUserID(r)
 	if error != nil {
@@ -320,19 +449,25 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if error := passaword.Prepare(); error != nil {
+	db, error := database.Connect()
+	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
+	defer db.Close()
 
-	db, error := database.Connect()
+	repository := repositories.NewRepositoryUsers(db)
+	user, error := repository.GetUser(userId)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewRepositoryUsers(db)
+	if error := passaword.Prepare(user); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
 	actualPasswordHash, error := repository.GetPassword(userId)
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -348,5 +483,81 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
+
+	// Tokens for scripts and bots outlive any session, so they're revoked
+	// with the password they were created under.
+	if error := repositories.NewRepositoryPersonalAccessTokens(db).RevokeAll(userId); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if passaword.RevokesOtherSessions() {
+		sessionID, error := authentication.GetSessionID(r)
+		if error != nil {
+			responses.Error(w, http.StatusUnauthorized, error)
+			return
+		}
+
+		repositorySessions := repositories.NewRepositorySessions(db)
+		if error := repositorySessions.RevokeAllExcept(userId, sessionID); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+	}
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	ID, error := strconv.ParseUint(params["id"], 10, 64)
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	request, error := ioutil.ReadAll(r.Body)
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var role models.Role
+	if error := json.Unmarshal(request, &role); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error := role.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryUsers(db)
+	if user, error := repository.GetUser(ID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	} else if user.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	}
+
+	if error := repository.UpdateRole(ID, role.Role); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// Tokens carry the role, so the user logs in again to get the new one.
+	if error := repositories.NewRepositorySessions(db).RevokeAll(ID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
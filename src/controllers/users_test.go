@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var userColumns = []string{"id", "name", "nick", "email", "role", "created_at"}
+
+func updateUser(body string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	UpdateUser(response, authenticatedRequest(
+		http.MethodPut, "/users/9", body,
+		authentication.Principal{UserID: 9, Role: "user"},
+		map[string]string{"id": "9"},
+	))
+	return response
+}
+
+// Nicks taken before the current rules don't block profile updates.
+func TestUpdateUserKeepsLegacyNick(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, name, nick, email, role, created_at from users").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(9, "Jane", "jane@home", "jane@example.com", "user", time.Now()))
+	mock.ExpectPrepare("update users set name = ").
+		ExpectExec().
+		WithArgs("Jane Doe", "jane@home", "jane@example.com", 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	response := updateUser(`{"name": "Jane Doe", "nick": "jane@home", "email": "jane@example.com"}`)
+	if response.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusNoContent, response.Body)
+	}
+}
+
+func TestUpdateUserValidatesNewNick(t *testing.T) {
+	mock := expectConnection(t)
+	mock.ExpectQuery("select id, name, nick, email, role, created_at from users").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(9, "Jane", "jane", "jane@example.com", "user", time.Now()))
+
+	response := updateUser(`{"name": "Jane", "nick": "jane@home", "email": "jane@example.com"}`)
+	if response.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", response.Code, http.StatusBadRequest, response.Body)
+	}
+}
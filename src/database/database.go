@@ -7,8 +7,12 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// Driver is the database/sql driver Connect opens, which tests replace with
+// a mock.
+var Driver = "mysql"
+
 func Connect() (*sql.DB, error) {
-	db, error := sql.Open("mysql", config.StringConnectDB)
+	db, error := sql.Open(Driver, config.StringConnectDB)
 
 	if error != nil {
 		return nil, error
@@ -0,0 +1,95 @@
+package lockout
+
+import (
+	"time"
+)
+
+type Attempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+func (attempts Attempts) RetryAfter(now time.Time) time.Duration {
+	if now.Before(attempts.LockedUntil) {
+		return attempts.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+type Lockout struct {
+	Key         string
+	IPAddress   string
+	Failures    int
+	LockedUntil time.Time
+}
+
+type Store interface {
+	Get(key string) (Attempts, error)
+	RegisterFailure(key string, policy Policy, now time.Time) (Attempts, error)
+	Reset(key string) error
+	Audit(lockout Lockout) error
+}
+
+type Policy struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Window    time.Duration
+}
+
+// Fail counts one more failure, forgetting old ones after the window, and
+// locks the key with a delay that doubles for every failure past the threshold.
+func (policy Policy) Fail(attempts Attempts, now time.Time) Attempts {
+	if now.Sub(attempts.LastFailureAt) > policy.Window {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+
+	if policy.Threshold > 0 && attempts.Failures >= policy.Threshold {
+		delay := policy.BaseDelay
+		for i := policy.Threshold; i < attempts.Failures && delay < policy.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+		attempts.LockedUntil = now.Add(delay)
+	}
+	return attempts
+}
+
+// Check returns how long the caller must wait before any of the keys can be
+// tried again, or zero when none of them is locked.
+func Check(store Store, keys []string, now time.Time) (time.Duration, error) {
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempts, error := store.Get(key)
+		if error != nil {
+			return 0, error
+		}
+		if wait := attempts.RetryAfter(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	return retryAfter, nil
+}
+
+func Fail(store Store, policy Policy, key, IPAddress string, now time.Time) error {
+	attempts, error := store.RegisterFailure(key, policy, now)
+	if error != nil {
+		return error
+	}
+
+	if attempts.RetryAfter(now) > 0 {
+		return store.Audit(Lockout{
+			Key:         key,
+			IPAddress:   IPAddress,
+			Failures:    attempts.Failures,
+			LockedUntil: attempts.LockedUntil,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+var policy = Policy{
+	Threshold: 3,
+	BaseDelay: time.Minute,
+	MaxDelay:  10 * time.Minute,
+	Window:    15 * time.Minute,
+}
+
+func TestPolicyLocksPastThresholdWithExponentialBackoff(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+
+	var attempts Attempts
+	for failure, delay := range want {
+		attempts = policy.Fail(attempts, now)
+		if attempts.Failures != failure+1 {
+			t.Fatalf("failures = %d, want %d", attempts.Failures, failure+1)
+		}
+		if got := attempts.RetryAfter(now); got != delay {
+			t.Errorf("failure %d: retry after %s, want %s", failure+1, got, delay)
+		}
+	}
+}
+
+func TestPolicyForgetsFailuresOutsideWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var attempts Attempts
+	attempts = policy.Fail(attempts, now)
+	attempts = policy.Fail(attempts, now)
+	attempts = policy.Fail(attempts, now.Add(policy.Window+time.Second))
+
+	if attempts.Failures != 1 {
+		t.Errorf("failures = %d, want 1", attempts.Failures)
+	}
+}
+
+func TestPolicyWithoutThresholdNeverLocks(t *testing.T) {
+	now := time.Now()
+	counting := Policy{Window: time.Minute}
+
+	var attempts Attempts
+	for i := 0; i < 100; i++ {
+		attempts = counting.Fail(attempts, now)
+	}
+	if attempts.Failures != 100 || attempts.RetryAfter(now) != 0 {
+		t.Errorf("attempts = %+v", attempts)
+	}
+}
+
+type auditingStore struct {
+	*memoryStore
+	lockouts []Lockout
+}
+
+func (store *auditingStore) Audit(lockout Lockout) error {
+	store.lockouts = append(store.lockouts, lockout)
+	return nil
+}
+
+func TestFailAndCheck(t *testing.T) {
+	store := &auditingStore{memoryStore: NewMemoryStore()}
+	now := time.Now()
+
+	for i := 0; i < policy.Threshold-1; i++ {
+		if error := Fail(store, policy, "user:1", "10.0.0.1", now); error != nil {
+			t.Fatal(error)
+		}
+	}
+	if retryAfter, _ := Check(store, []string{"user:1", "ip:10.0.0.1"}, now); retryAfter != 0 {
+		t.Fatalf("locked before the threshold for %s", retryAfter)
+	}
+	if len(store.lockouts) != 0 {
+		t.Fatalf("audited %d lockouts before the threshold", len(store.lockouts))
+	}
+
+	if error := Fail(store, policy, "user:1", "10.0.0.1", now); error != nil {
+		t.Fatal(error)
+	}
+	retryAfter, error := Check(store, []string{"ip:10.0.0.1", "user:1"}, now)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if retryAfter != policy.BaseDelay {
+		t.Errorf("retry after %s, want %s", retryAfter, policy.BaseDelay)
+	}
+	if len(store.lockouts) != 1 || store.lockouts[0].Key != "user:1" || store.lockouts[0].IPAddress != "10.0.0.1" {
+		t.Errorf("lockouts = %+v", store.lockouts)
+	}
+
+	if retryAfter, _ := Check(store, []string{"user:2"}, now); retryAfter != 0 {
+		t.Errorf("other key locked for %s", retryAfter)
+	}
+	if retryAfter, _ := Check(store, []string{"user:1"}, now.Add(policy.BaseDelay)); retryAfter != 0 {
+		t.Errorf("still locked after the delay for %s", retryAfter)
+	}
+
+	if error := store.Reset("user:1"); error != nil {
+		t.Fatal(error)
+	}
+	if attempts, _ := store.Get("user:1"); attempts.Failures != 0 {
+		t.Errorf("failures = %d after reset", attempts.Failures)
+	}
+}
+
+func TestMemoryStorePrunesStaleKeys(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	store.RegisterFailure("stale", policy, now)
+	store.RegisterFailure("fresh", policy, now.Add(policy.Window+2*time.Minute))
+
+	if _, ok := store.attempts["stale"]; ok {
+		t.Error("stale key kept")
+	}
+	if _, ok := store.attempts["fresh"]; !ok {
+		t.Error("fresh key pruned")
+	}
+}
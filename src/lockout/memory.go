@@ -0,0 +1,77 @@
+package lockout
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mutex    sync.Mutex
+	attempts map[string]Attempts
+	prunedAt time.Time
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{attempts: map[string]Attempts{}}
+}
+
+func (store *memoryStore) Get(key string) (Attempts, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	attempts, ok := store.attempts[key]
+	if !ok {
+		return Attempts{Key: key}, nil
+	}
+	return attempts, nil
+}
+
+func (store *memoryStore) RegisterFailure(key string, policy Policy, now time.Time) (Attempts, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	attempts, ok := store.attempts[key]
+	if !ok {
+		attempts = Attempts{Key: key}
+	}
+	attempts = policy.Fail(attempts, now)
+	store.attempts[key] = attempts
+
+	store.prune(policy, now)
+
+	return attempts, nil
+}
+
+func (store *memoryStore) Reset(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.attempts, key)
+	return nil
+}
+
+func (store *memoryStore) Audit(lockout Lockout) error {
+	log.Printf("\nlockout %s from %s after %d failures until %s",
+		lockout.Key,
+		lockout.IPAddress,
+		lockout.Failures,
+		lockout.LockedUntil.Format(time.RFC3339),
+	)
+	return nil
+}
+
+// prune drops keys that are neither locked nor inside the failure window, so
+// the map doesn't grow with every address that ever failed a login.
+func (store *memoryStore) prune(policy Policy, now time.Time) {
+	if now.Sub(store.prunedAt) < time.Minute {
+		return
+	}
+	store.prunedAt = now
+
+	for key, attempts := range store.attempts {
+		if attempts.RetryAfter(now) == 0 && now.Sub(attempts.LastFailureAt) > policy.Window {
+			delete(store.attempts, key)
+		}
+	}
+}
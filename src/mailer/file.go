@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+var fileLock sync.Mutex
+
+type fileMailer struct {
+	path string
+	from string
+}
+
+func NewFile(path, from string) *fileMailer {
+	return &fileMailer{path, from}
+}
+
+func (mailer fileMailer) Send(message Message) error {
+	fileLock.Lock()
+	defer fileLock.Unlock()
+
+	file, error := os.OpenFile(mailer.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if error != nil {
+		return error
+	}
+	defer file.Close()
+
+	if _, error := file.Write(append(format(mailer.from, message), "\r\n\r\n"...)); error != nil {
+		return error
+	}
+	return nil
+}
+
+type logMailer struct {
+	from string
+}
+
+func NewLog(from string) *logMailer {
+	return &logMailer{from}
+}
+
+func (mailer logMailer) Send(message Message) error {
+	log.Printf("\n%s", format(mailer.from, message))
+	return nil
+}
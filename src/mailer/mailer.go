@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"social-network/src/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// New returns the mailer selected by the MAIL_DRIVER setting, falling back to
+// the log sink so local environments never need a real mail server.
+func New() Mailer {
+	switch config.MailDriver {
+	case "smtp":
+		return NewSMTP(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom)
+	case "file":
+		return NewFile(config.MailFile, config.MailFrom)
+	default:
+		return NewLog(config.MailFrom)
+	}
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+type smtpMailer struct {
+	address string
+	auth    smtp.Auth
+	from    string
+}
+
+func NewSMTP(host string, port int, username, password, from string) *smtpMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{fmt.Sprintf("%s:%d", host, port), auth, from}
+}
+
+func (mailer smtpMailer) Send(message Message) error {
+	return smtp.SendMail(mailer.address, mailer.auth, mailer.from, []string{message.To}, format(mailer.from, message))
+}
+
+func format(from string, message Message) []byte {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "From: %s\r\n", from)
+	fmt.Fprintf(&builder, "To: %s\r\n", message.To)
+	fmt.Fprintf(&builder, "Subject: %s\r\n", message.Subject)
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(message.Body)
+	return []byte(builder.String())
+}
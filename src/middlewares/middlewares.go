@@ -1,18 +1,120 @@
 package middlewares
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"social-network/src/authentication"
+	"social-network/src/database"
+	"social-network/src/repositories"
 	"social-network/src/responses"
+	"social-network/src/security"
 )
 
 func Authenticate(nextFunc http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if error := authentication.ValidateToken(r); error != nil {
+		r, statusCode, error := authenticate(r)
+		if error != nil {
+			responses.Error(w, statusCode, error)
+			return
+		}
+		nextFunc(w, r)
+	}
+}
+
+// authenticate accepts either a JWT bound to an active session or a personal
+// access token, parsing it once and recording the principal it resolves to
+// on the request.
+func authenticate(r *http.Request) (*http.Request, int, error) {
+	token, isPersonalAccessToken := authentication.GetPersonalAccessToken(r)
+
+	var accessToken authentication.AccessToken
+	if !isPersonalAccessToken {
+		var error error
+		if accessToken, error = authentication.ParseAccessToken(token); error != nil {
+			return nil, http.StatusUnauthorized, error
+		}
+	}
+
+	db, error := database.Connect()
+	if error != nil {
+		return nil, http.StatusInternalServerError, error
+	}
+	defer db.Close()
+
+	if isPersonalAccessToken {
+		repository := repositories.NewRepositoryPersonalAccessTokens(db)
+		personalAccessToken, error := repository.GetForTokenHash(security.HashToken(token))
+		if error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+		if !personalAccessToken.Active() {
+			return nil, http.StatusUnauthorized, errors.New("invalid token")
+		}
+
+		if error := repository.Touch(personalAccessToken.ID); error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		role, error := repositories.NewRepositoryUsers(db).GetRole(personalAccessToken.UserID)
+		if error != nil {
+			return nil, http.StatusInternalServerError, error
+		}
+
+		return authentication.WithPrincipal(r, authentication.Principal{
+			UserID:                personalAccessToken.UserID,
+			PersonalAccessTokenID: personalAccessToken.ID,
+			Scopes:                personalAccessToken.Scopes,
+			Role:                  role,
+		}), 0, nil
+	}
+
+	repository := repositories.NewRepositorySessions(db)
+	active, error := repository.IsActive(accessToken.SessionID)
+	if error != nil {
+		return nil, http.StatusInternalServerError, error
+	}
+	if !active {
+		return nil, http.StatusUnauthorized, errors.New("session revoked")
+	}
+
+	return authentication.WithPrincipal(r, authentication.Principal{
+		UserID:    accessToken.UserID,
+		SessionID: accessToken.SessionID,
+		Scopes:    accessToken.Scopes,
+		Role:      accessToken.Role,
+	}), 0, nil
+}
+
+func Authorize(scope string, nextFunc http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, error := authentication.GetPrincipal(r)
+		if error != nil {
+			responses.Error(w, http.StatusUnauthorized, error)
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			responses.Error(w, http.StatusForbidden, fmt.Errorf("token without %s scope", scope))
+			return
+		}
+		nextFunc(w, r)
+	}
+}
+
+func RequireRole(role string, nextFunc http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, error := authentication.GetPrincipal(r)
+		if error != nil {
 			responses.Error(w, http.StatusUnauthorized, error)
 			return
 		}
+
+		if !principal.HasRole(role) {
+			responses.Error(w, http.StatusForbidden, fmt.Errorf("%s role required", role))
+			return
+		}
 		nextFunc(w, r)
 	}
 }
@@ -0,0 +1,178 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/security"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func serve(handler http.HandlerFunc, r *http.Request) int {
+	response := httptest.NewRecorder()
+	handler(response, r)
+	return response.Code
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestAuthorize(t *testing.T) {
+	handler := Authorize(authentication.ScopePostsWrite, ok)
+	request := httptest.NewRequest(http.MethodPost, "/posts", nil)
+
+	tests := []struct {
+		name       string
+		scopes     []string
+		statusCode int
+	}{
+		{"scope held", []string{authentication.ScopePostsRead, authentication.ScopePostsWrite}, http.StatusOK},
+		{"read only", []string{authentication.ScopePostsRead}, http.StatusForbidden},
+		{"no scopes", nil, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := authentication.WithPrincipal(request, authentication.Principal{UserID: 7, Scopes: test.scopes})
+			if statusCode := serve(handler, r); statusCode != test.statusCode {
+				t.Errorf("status = %d, want %d", statusCode, test.statusCode)
+			}
+		})
+	}
+
+	if statusCode := serve(handler, request); statusCode != http.StatusUnauthorized {
+		t.Errorf("status without principal = %d, want %d", statusCode, http.StatusUnauthorized)
+	}
+}
+
+// useMockDB points database.Connect to a mock, which goes away with the
+// connection the middleware closes.
+func useMockDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	dsn := fmt.Sprintf("%s %d", t.Name(), time.Now().UnixNano())
+	db, mock, error := sqlmock.NewWithDSN(dsn)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	driver, stringConnectDB, secretKey := database.Driver, config.StringConnectDB, config.SecretKey
+	database.Driver, config.StringConnectDB, config.SecretKey = "sqlmock", dsn, []byte("test secret")
+	t.Cleanup(func() {
+		database.Driver, config.StringConnectDB, config.SecretKey = driver, stringConnectDB, secretKey
+		db.Close()
+		if error := mock.ExpectationsWereMet(); error != nil {
+			t.Error(error)
+		}
+	})
+	return mock
+}
+
+func expectSession(mock sqlmock.Sqlmock, revokedAt *time.Time) {
+	mock.ExpectQuery("from sessions where id = ").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "refresh_token_hash", "user_agent", "ip_address", "scopes", "client_id",
+			"expires_at", "revoked_at", "created_at",
+		}).AddRow(3, 7, "hash", "test", "127.0.0.1", "posts:read", "", time.Now().Add(time.Hour), revokedAt, time.Now()))
+}
+
+func expectPersonalAccessToken(mock sqlmock.Sqlmock, token string, revokedAt *time.Time) {
+	mock.ExpectQuery("from personal_access_tokens where token_hash = ").
+		WithArgs(security.HashToken(token)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "name", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at",
+		}).AddRow(2, 7, "bot", "posts:read", nil, nil, revokedAt, time.Now()))
+}
+
+// authenticateAs runs the middleware with the bearer token, returning the
+// status and the principal the next handler got.
+func authenticateAs(token string) (int, authentication.Principal) {
+	var principal authentication.Principal
+	handler := Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = authentication.GetPrincipal(r)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return serve(handler, r), principal
+}
+
+func TestAuthenticateSession(t *testing.T) {
+	mock := useMockDB(t)
+	expectSession(mock, nil)
+
+	token, error := authentication.GenerateToken(7, 3, []string{authentication.ScopePostsRead}, "moderator")
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	statusCode, principal := authenticateAs(token)
+	if statusCode != http.StatusOK {
+		t.Fatalf("status = %d", statusCode)
+	}
+	if principal.UserID != 7 || principal.SessionID != 3 || principal.Role != "moderator" ||
+		principal.IsPersonalAccessToken() || !principal.HasScope(authentication.ScopePostsRead) {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+}
+
+func TestAuthenticatePersonalAccessToken(t *testing.T) {
+	mock := useMockDB(t)
+	token := authentication.PersonalAccessTokenPrefix + "token"
+	expectPersonalAccessToken(mock, token, nil)
+	mock.ExpectPrepare("update personal_access_tokens set last_used_at").
+		ExpectExec().
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("select role from users").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("user"))
+
+	statusCode, principal := authenticateAs(token)
+	if statusCode != http.StatusOK {
+		t.Fatalf("status = %d", statusCode)
+	}
+	if principal.UserID != 7 || principal.PersonalAccessTokenID != 2 || principal.SessionID != 0 || principal.Role != "user" {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+}
+
+func TestAuthenticateRejectsRevokedCredentials(t *testing.T) {
+	revokedAt := time.Now().Add(-time.Minute)
+
+	t.Run("session", func(t *testing.T) {
+		expectSession(useMockDB(t), &revokedAt)
+		token, error := authentication.GenerateToken(7, 3, authentication.AllScopes, "user")
+		if error != nil {
+			t.Fatal(error)
+		}
+		if statusCode, _ := authenticateAs(token); statusCode != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", statusCode, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("personal access token", func(t *testing.T) {
+		token := authentication.PersonalAccessTokenPrefix + "token"
+		expectPersonalAccessToken(useMockDB(t), token, &revokedAt)
+		if statusCode, _ := authenticateAs(token); statusCode != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", statusCode, http.StatusUnauthorized)
+		}
+	})
+
+	// Tokens that don't parse are turned down before the database.
+	t.Run("malformed", func(t *testing.T) {
+		for _, token := range []string{"", "not a token"} {
+			if statusCode, _ := authenticateAs(token); statusCode != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", statusCode, http.StatusUnauthorized)
+			}
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Bookmark is the body of a bookmark request, optionally filing the post in
+// one of the collections of the user.
+type Bookmark struct {
+	CollectionID *uint64 `json:"collection_id,omitempty"`
+}
+
+type BookmarkCollection struct {
+	ID             uint64    `json:"id,omitempty"`
+	UserID         uint64    `json:"-"`
+	Name           string    `json:"name,omitempty"`
+	BookmarksCount uint64    `json:"bookmarks_count"`
+	CreatedAt      time.Time `json:"created_at,omitempty"`
+}
+
+func (collection *BookmarkCollection) Prepare() error {
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" {
+		return errors.New("name required")
+	}
+	if len([]rune(collection.Name)) > 100 {
+		return errors.New("name can't be longer than 100 characters")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrepareBookmarkCollection(t *testing.T) {
+	collection := BookmarkCollection{Name: "  Recipes  "}
+	if error := collection.Prepare(); error != nil {
+		t.Fatal(error)
+	}
+	if collection.Name != "Recipes" {
+		t.Errorf("name = %q, want %q", collection.Name, "Recipes")
+	}
+
+	for _, name := range []string{"", "   ", strings.Repeat("é", 101)} {
+		collection := BookmarkCollection{Name: name}
+		if error := collection.Prepare(); error == nil {
+			t.Errorf("name %q accepted", name)
+		}
+	}
+
+	collection = BookmarkCollection{Name: strings.Repeat("é", 100)}
+	if error := collection.Prepare(); error != nil {
+		t.Errorf("name of 100 characters rejected: %v", error)
+	}
+}
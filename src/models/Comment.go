@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type Comment struct {
+	ID         uint64     `json:"id,omitempty"`
+	PostID     uint64     `json:"post_id,omitempty"`
+	AuthorID   uint64     `json:"author_id,omitempty"`
+	AuthorNick string     `json:"author_nick,omitempty"`
+	Content    string     `json:"content,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+func (comment *Comment) validate() error {
+	if comment.Content == "" {
+		return errors.New("content required")
+	}
+	if len([]rune(comment.Content)) > 300 {
+		return errors.New("content can't be longer than 300 characters")
+	}
+	return nil
+}
+
+func (comment *Comment) format() {
+	comment.Content = strings.TrimSpace(comment.Content)
+}
+
+func (comment *Comment) Prepare() error {
+	comment.format()
+
+	if error := comment.validate(); error != nil {
+		return error
+	}
+
+	return nil
+}
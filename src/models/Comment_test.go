@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommentPrepare(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"content", " comment ", true},
+		{"300 characters", strings.Repeat("é", 300), true},
+		{"empty", "", false},
+		{"blank", "   ", false},
+		{"too long", strings.Repeat("é", 301), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			comment := Comment{Content: test.content}
+			error := comment.Prepare()
+			if test.valid && error != nil {
+				t.Fatalf("rejected: %v", error)
+			}
+			if !test.valid && error == nil {
+				t.Fatal("accepted")
+			}
+			if test.valid && comment.Content != strings.TrimSpace(test.content) {
+				t.Errorf("content = %q", comment.Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type EmailVerification struct {
+	ID        uint64
+	UserID    uint64
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (emailVerification EmailVerification) Valid() bool {
+	return emailVerification.ID != 0 && emailVerification.UsedAt == nil && time.Now().Before(emailVerification.ExpiresAt)
+}
+
+type VerifyEmail struct {
+	Token string `json:"token"`
+}
+
+func (verifyEmail *VerifyEmail) Prepare() error {
+	verifyEmail.Token = strings.TrimSpace(verifyEmail.Token)
+	if verifyEmail.Token == "" {
+		return errors.New("token required")
+	}
+	return nil
+}
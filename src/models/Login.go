@@ -0,0 +1,29 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Login holds the credentials of the password login. The identifier is
+// either the nick or the e-mail of the user; the e-mail field is still
+// accepted for clients that send it.
+type Login struct {
+	Identifier string `json:"identifier"`
+	Email      string `json:"email,omitempty"`
+	Password   string `json:"password"`
+}
+
+func (login *Login) Prepare() error {
+	login.Identifier = strings.TrimSpace(login.Identifier)
+	if login.Identifier == "" {
+		login.Identifier = strings.TrimSpace(login.Email)
+	}
+	if login.Identifier == "" {
+		return errors.New("identifier required")
+	}
+	if login.Password == "" {
+		return errors.New("password required")
+	}
+	return nil
+}
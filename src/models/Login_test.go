@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestLoginPrepare(t *testing.T) {
+	tests := []struct {
+		name       string
+		login      Login
+		identifier string
+		valid      bool
+	}{
+		{"identifier", Login{Identifier: " jane ", Password: "secret"}, "jane", true},
+		{"e-mail of older clients", Login{Email: "jane@example.com", Password: "secret"}, "jane@example.com", true},
+		{"identifier over e-mail", Login{Identifier: "jane", Email: "jane@example.com", Password: "secret"}, "jane", true},
+		{"no identifier", Login{Identifier: "  ", Password: "secret"}, "", false},
+		{"no password", Login{Identifier: "jane"}, "jane", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			error := test.login.Prepare()
+			if test.valid && error != nil {
+				t.Fatalf("rejected: %v", error)
+			}
+			if !test.valid && error == nil {
+				t.Fatal("accepted")
+			}
+			if test.valid && test.login.Identifier != test.identifier {
+				t.Errorf("identifier = %q, want %q", test.login.Identifier, test.identifier)
+			}
+		})
+	}
+}
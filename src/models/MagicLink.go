@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type MagicLink struct {
+	ID         uint64
+	UserID     uint64
+	Email      string
+	LinkIDHash string
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+}
+
+func (magicLink MagicLink) Valid() bool {
+	return magicLink.ID != 0 && magicLink.UsedAt == nil && time.Now().Before(magicLink.ExpiresAt)
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+func (request *MagicLinkRequest) Prepare() error {
+	request.Email = strings.TrimSpace(request.Email)
+	if request.Email == "" {
+		return errors.New("e-mail required")
+	}
+	return nil
+}
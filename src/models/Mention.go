@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxNickLength = 50
+
+// Mention is an @nick in the title or the content of a post. Start and End
+// are offsets in Unicode code points of the @nick in that field, so clients
+// can turn it into a link. Only mentions of existing users are kept, and
+// UserNick is the current nick of the user, which differs from Nick once
+// they rename themselves.
+type Mention struct {
+	Field    string `json:"field"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Nick     string `json:"nick"`
+	UserID   uint64 `json:"user_id"`
+	UserNick string `json:"user_nick"`
+}
+
+// ExtractMentions finds the @nicks of the post. An @ only starts a mention at
+// the beginning of a word, so e-mail addresses are left alone. Nicks are made
+// of letters, digits, _, . and - and never end with . or -, so a nick is read
+// up to the last character it can have.
+func ExtractMentions(title, content string) []Mention {
+	return append(extractMentions("title", title), extractMentions("content", content)...)
+}
+
+func extractMentions(field, text string) []Mention {
+	mentions := []Mention{}
+	runes := []rune(text)
+	for index := 0; index < len(runes); index++ {
+		if runes[index] != '@' || (index > 0 && (isNickRune(runes[index-1]) || runes[index-1] == '@')) {
+			continue
+		}
+
+		end := index + 1
+		for end < len(runes) && isNickRune(runes[end]) {
+			end++
+		}
+		// Punctuation closing a sentence isn't part of the nick.
+		for end > index+1 && strings.ContainsRune(".-", runes[end-1]) {
+			end--
+		}
+
+		if nick := string(runes[index+1 : end]); nick != "" && end-index-1 <= maxNickLength {
+			mentions = append(mentions, Mention{Field: field, Start: index, End: end, Nick: nick})
+		}
+		index = end - 1
+	}
+	return mentions
+}
+
+func isNickRune(r rune) bool {
+	return r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Mention
+	}{
+		{"no mentions", []Mention{}},
+		{"@ana", []Mention{{Start: 0, End: 4, Nick: "ana"}}},
+		{"hi @ana and @bob_1!", []Mention{{Start: 3, End: 7, Nick: "ana"}, {Start: 12, End: 18, Nick: "bob_1"}}},
+		{"thanks @ana.", []Mention{{Start: 7, End: 11, Nick: "ana"}}},
+		{"ask @ana.silva-- now", []Mention{{Start: 4, End: 14, Nick: "ana.silva"}}},
+		{"(@ana)", []Mention{{Start: 1, End: 5, Nick: "ana"}}},
+		{"ação @joão", []Mention{{Start: 5, End: 10, Nick: "joão"}}},
+		{"write to ana@example.com", []Mention{}},
+		{"@@ana and @ and @.", []Mention{}},
+	}
+
+	for _, test := range tests {
+		want := test.want
+		for index := range want {
+			want[index].Field = "content"
+		}
+		if got := ExtractMentions("", test.text); !reflect.DeepEqual(got, want) {
+			t.Errorf("ExtractMentions(%q) = %+v, want %+v", test.text, got, want)
+		}
+	}
+}
+
+func TestExtractMentionsFields(t *testing.T) {
+	got := ExtractMentions("@ana", "@bob")
+	want := []Mention{
+		{Field: "title", Start: 0, End: 4, Nick: "ana"},
+		{Field: "content", Start: 0, End: 4, Nick: "bob"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractMentions = %+v, want %+v", got, want)
+	}
+}
+
+func TestExtractMentionsSkipsLongNicks(t *testing.T) {
+	long := strings.Repeat("a", maxNickLength+1)
+	if got := ExtractMentions("", "@"+long+" @"+long[1:]); len(got) != 1 || got[0].Nick != long[1:] {
+		t.Errorf("ExtractMentions = %+v, want only the %d character nick", got, maxNickLength)
+	}
+}
+
+// Every valid nick must be mentioned as a whole.
+func TestValidNicksCanBeMentioned(t *testing.T) {
+	for _, nick := range []string{"ana", "ana.silva", "ana-silva", "ana_1", "joão", strings.Repeat("a", maxNickLength)} {
+		if error := (User{Nick: nick}).ValidateNick(); error != nil {
+			t.Errorf("ValidateNick(%q) = %v", nick, error)
+			continue
+		}
+		if got := ExtractMentions("", "@"+nick+"."); len(got) != 1 || got[0].Nick != nick {
+			t.Errorf("mentions of %q = %+v", nick, got)
+		}
+	}
+
+	for _, nick := range []string{"ana@example.com", "ana silva", "ana.", "ana-", "#ana", strings.Repeat("a", maxNickLength+1)} {
+		if error := (User{Nick: nick}).ValidateNick(); error == nil {
+			t.Errorf("ValidateNick(%q) accepted", nick)
+		}
+	}
+}
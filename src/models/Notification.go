@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+const NotificationMention = "mention"
+
+// Notification tells the user that someone else did something involving
+// them, like mentioning them in a post.
+type Notification struct {
+	ID        uint64    `json:"id"`
+	Kind      string    `json:"kind"`
+	Actor     User      `json:"actor"`
+	PostID    uint64    `json:"post_id,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type OAuthClient struct {
+	ID               uint64    `json:"id,omitempty"`
+	ClientID         string    `json:"client_id,omitempty"`
+	ClientSecret     string    `json:"client_secret,omitempty"`
+	ClientSecretHash string    `json:"-"`
+	Name             string    `json:"name,omitempty"`
+	RedirectURIs     []string  `json:"redirect_uris"`
+	Scopes           []string  `json:"scopes"`
+	Confidential     bool      `json:"confidential"`
+	OwnerID          uint64    `json:"owner_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at,omitempty"`
+}
+
+func (client *OAuthClient) validate() error {
+	if client.Name == "" {
+		return errors.New("name required")
+	}
+	if len(client.Name) > 100 {
+		return errors.New("name must have at most 100 characters")
+	}
+	if len(client.RedirectURIs) == 0 {
+		return errors.New("at least one redirect uri required")
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		address, error := url.Parse(redirectURI)
+		if error != nil || !address.IsAbs() || address.Fragment != "" || strings.ContainsAny(redirectURI, " ") {
+			return errors.New("redirect uris must be absolute urls without fragment")
+		}
+	}
+	if len(client.Scopes) == 0 {
+		return errors.New("at least one scope required")
+	}
+	return nil
+}
+
+func (client *OAuthClient) format() {
+	client.Name = strings.TrimSpace(client.Name)
+	for i := range client.RedirectURIs {
+		client.RedirectURIs[i] = strings.TrimSpace(client.RedirectURIs[i])
+	}
+}
+
+func (client *OAuthClient) Prepare() error {
+	client.format()
+
+	if error := client.validate(); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (client OAuthClient) HasRedirectURI(redirectURI string) bool {
+	for _, registered := range client.RedirectURIs {
+		if registered == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+type OAuthAuthorizationCode struct {
+	ID                  uint64
+	CodeHash            string
+	ClientID            string
+	UserID              uint64
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	SessionID           uint64
+	ExpiresAt           time.Time
+	UsedAt              *time.Time
+}
+
+type OAuthAuthorization struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Approved            bool   `json:"approved"`
+}
+
+type OAuthConsent struct {
+	ClientID    string   `json:"client_id"`
+	Name        string   `json:"name"`
+	RedirectURI string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+}
+
+type OAuthRedirect struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type OAuthIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+type OAuthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
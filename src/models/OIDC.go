@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type OIDCState struct {
+	ID           uint64
+	StateHash    string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+type UserIdentity struct {
+	ID       uint64
+	UserID   uint64
+	Provider string
+	Subject  string
+	Email    string
+}
@@ -8,16 +8,23 @@ import (
 type Password struct {
 	ActualPassword string `json:"actual_password"`
 	NewPassword    string `json:"new_password"`
+	// RevokeOtherSessions is true when left out, so only an explicit false
+	// keeps the other sessions signed in.
+	RevokeOtherSessions *bool `json:"revoke_other_sessions"`
 }
 
-func (password *Password) validate() error {
+func (password Password) RevokesOtherSessions() bool {
+	return password.RevokeOtherSessions == nil || *password.RevokeOtherSessions
+}
+
+func (password *Password) validate(user User) error {
 	if password.ActualPassword == "" {
 		return errors.New("actual password required")
 	}
 	if password.NewPassword == "" {
 		return errors.New("new password required")
 	}
-	return nil
+	return security.ValidatePassword(password.NewPassword, user.Nick, user.Email)
 }
 
 func (password *Password) format() error {
@@ -30,8 +37,10 @@ func (password *Password) format() error {
 	return nil
 }
 
-func (password *Password) Prepare() error {
-	if error := password.validate(); error != nil {
+// Prepare validates the new password against the policy, using the nick and
+// e-mail of its owner, and hashes it.
+func (password *Password) Prepare(user User) error {
+	if error := password.validate(user); error != nil {
 		return error
 	}
 
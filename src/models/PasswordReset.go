@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"social-network/src/security"
+	"strings"
+	"time"
+)
+
+type PasswordReset struct {
+	ID        uint64
+	UserID    uint64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (passwordReset PasswordReset) Valid() bool {
+	return passwordReset.ID != 0 && passwordReset.UsedAt == nil && time.Now().Before(passwordReset.ExpiresAt)
+}
+
+type ForgotPassword struct {
+	Email string `json:"email"`
+}
+
+func (forgotPassword *ForgotPassword) Prepare() error {
+	forgotPassword.Email = strings.TrimSpace(forgotPassword.Email)
+	if forgotPassword.Email == "" {
+		return errors.New("e-mail required")
+	}
+	return nil
+}
+
+type ResetPassword struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (resetPassword *ResetPassword) validate(user User) error {
+	if resetPassword.Token == "" {
+		return errors.New("token required")
+	}
+	if resetPassword.NewPassword == "" {
+		return errors.New("new password required")
+	}
+	return security.ValidatePassword(resetPassword.NewPassword, user.Nick, user.Email)
+}
+
+func (resetPassword *ResetPassword) format() error {
+	resetPassword.Token = strings.TrimSpace(resetPassword.Token)
+	newPasswordHash, error := security.Hash(resetPassword.NewPassword)
+	if error != nil {
+		return error
+	}
+	resetPassword.NewPassword = string(newPasswordHash)
+
+	return nil
+}
+
+// Prepare validates the new password against the policy, using the nick and
+// e-mail of the user the token belongs to, and hashes it.
+func (resetPassword *ResetPassword) Prepare(user User) error {
+	if error := resetPassword.validate(user); error != nil {
+		return error
+	}
+
+	if error := resetPassword.format(); error != nil {
+		return error
+	}
+
+	return nil
+}
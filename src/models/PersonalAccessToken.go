@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type PersonalAccessToken struct {
+	ID         uint64     `json:"id,omitempty"`
+	UserID     uint64     `json:"user_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Token      string     `json:"token,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+}
+
+func (token PersonalAccessToken) Active() bool {
+	if token.ID == 0 || token.RevokedAt != nil {
+		return false
+	}
+	return token.ExpiresAt == nil || time.Now().Before(*token.ExpiresAt)
+}
+
+func (token *PersonalAccessToken) validate() error {
+	if token.Name == "" {
+		return errors.New("name required")
+	}
+	if len(token.Name) > 100 {
+		return errors.New("name must have at most 100 characters")
+	}
+	if len(token.Scopes) == 0 {
+		return errors.New("at least one scope required")
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return errors.New("expiration must be in the future")
+	}
+	return nil
+}
+
+func (token *PersonalAccessToken) format() {
+	token.Name = strings.TrimSpace(token.Name)
+}
+
+func (token *PersonalAccessToken) Prepare() error {
+	token.format()
+
+	if error := token.validate(); error != nil {
+		return error
+	}
+
+	return nil
+}
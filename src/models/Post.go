@@ -7,17 +7,46 @@ import (
 )
 
 type Post struct {
-	ID         uint64    `json:"id,omitempty"`
-	Title      string    `json:"title,omitempty"`
-	Content    string    `json:"content,omitempty"`
-	AuthorID   uint64    `json:"author_id,omitempty"`
-	AuthorNick string    `json:"author_nick,omitempty"`
-	Likes      uint64    `json:"likes"`
-	CreatedAt  time.Time `json:"created_at,omitempty"`
+	ID             uint64            `json:"id,omitempty"`
+	Title          string            `json:"title,omitempty"`
+	Content        string            `json:"content,omitempty"`
+	Tags           []string          `json:"tags"`
+	Mentions       []Mention         `json:"mentions"`
+	AuthorID       uint64            `json:"author_id,omitempty"`
+	AuthorNick     string            `json:"author_nick,omitempty"`
+	InReplyToID    *uint64           `json:"in_reply_to_id,omitempty"`
+	QuotedPostID   *uint64           `json:"quoted_post_id,omitempty"`
+	QuotedPost     *Post             `json:"quoted_post,omitempty"`
+	Likes          uint64            `json:"likes"`
+	LikedByMe      bool              `json:"liked_by_me"`
+	Reactions      map[string]uint64 `json:"reactions"`
+	MyReactions    []string          `json:"my_reactions"`
+	CommentsCount  uint64            `json:"comments_count"`
+	RepliesCount   uint64            `json:"replies_count"`
+	RepostsCount   uint64            `json:"reposts_count"`
+	RepostedByMe   bool              `json:"reposted_by_me"`
+	RepostedBy     *User             `json:"reposted_by,omitempty"`
+	BookmarkedByMe bool              `json:"bookmarked_by_me"`
+	CreatedAt      time.Time         `json:"created_at,omitempty"`
+}
+
+// Thread is the conversation around a post: the posts it replies to, from
+// the root down, and a page of the replies below it.
+type Thread struct {
+	Ancestors []Post        `json:"ancestors"`
+	Post      Post          `json:"post"`
+	Replies   []ThreadReply `json:"replies"`
+}
+
+// ThreadReply is a reply along with its own replies, down to the requested
+// depth. Replies not included are still counted in replies_count.
+type ThreadReply struct {
+	Post
+	Replies []ThreadReply `json:"replies"`
 }
 
 func (post *Post) validate() error {
-	if post.Title == "" {
+	if post.Title == "" && post.InReplyToID == nil {
 		return errors.New("title required")
 	}
 	if post.Content == "" {
@@ -29,6 +58,8 @@ func (post *Post) validate() error {
 func (post *Post) format() {
 	post.Title = strings.TrimSpace(post.Title)
 	post.Content = strings.TrimSpace(post.Content)
+	post.Tags = ExtractTags(post.Title, post.Content)
+	post.Mentions = ExtractMentions(post.Title, post.Content)
 }
 
 func (post *Post) Prepare() error {
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"fmt"
+	"social-network/src/config"
+	"strings"
+	"time"
+)
+
+type Reaction struct {
+	Kind      string    `json:"kind"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// ValidateReactionKind accepts only the kinds of reaction configured.
+func ValidateReactionKind(kind string) error {
+	for _, reactionKind := range config.ReactionKinds {
+		if kind == reactionKind {
+			return nil
+		}
+	}
+	return fmt.Errorf("reaction must be one of %s", strings.Join(config.ReactionKinds, ", "))
+}
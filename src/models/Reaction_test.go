@@ -0,0 +1,23 @@
+package models
+
+import (
+	"social-network/src/config"
+	"testing"
+)
+
+func TestValidateReactionKind(t *testing.T) {
+	kinds := config.ReactionKinds
+	config.ReactionKinds = []string{"heart", "laugh"}
+	t.Cleanup(func() { config.ReactionKinds = kinds })
+
+	for _, kind := range []string{"heart", "laugh"} {
+		if error := ValidateReactionKind(kind); error != nil {
+			t.Errorf("kind %q rejected: %v", kind, error)
+		}
+	}
+	for _, kind := range []string{"", "wow", "Heart"} {
+		if error := ValidateReactionKind(kind); error == nil {
+			t.Errorf("kind %q accepted", kind)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "errors"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// HasRole tells if role grants at least the permissions of required, since
+// every role includes the ones below it.
+func HasRole(role, required string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[required]
+}
+
+type Role struct {
+	Role string `json:"role"`
+}
+
+func (role *Role) Prepare() error {
+	if _, ok := roleRanks[role.Role]; !ok {
+		return errors.New("role must be user, moderator or admin")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		role, required string
+		want           bool
+	}{
+		{RoleUser, RoleUser, true},
+		{RoleUser, RoleModerator, false},
+		{RoleModerator, RoleUser, true},
+		{RoleModerator, RoleModerator, true},
+		{RoleModerator, RoleAdmin, false},
+		{RoleAdmin, RoleModerator, true},
+		{"", RoleUser, false},
+		{"root", RoleUser, false},
+	}
+
+	for _, test := range tests {
+		if got := HasRole(test.role, test.required); got != test.want {
+			t.Errorf("HasRole(%q, %q) = %v, want %v", test.role, test.required, got, test.want)
+		}
+	}
+}
+
+func TestRolePrepare(t *testing.T) {
+	for _, role := range []string{RoleUser, RoleModerator, RoleAdmin} {
+		if error := (&Role{Role: role}).Prepare(); error != nil {
+			t.Errorf("role %q rejected: %v", role, error)
+		}
+	}
+	for _, role := range []string{"", "Admin", "root"} {
+		if error := (&Role{Role: role}).Prepare(); error == nil {
+			t.Errorf("role %q accepted", role)
+		}
+	}
+}
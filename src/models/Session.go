@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Session struct {
+	ID               uint64     `json:"id,omitempty"`
+	UserID           uint64     `json:"user_id,omitempty"`
+	RefreshTokenHash string     `json:"-"`
+	UserAgent        string     `json:"user_agent"`
+	IPAddress        string     `json:"ip_address"`
+	Scopes           []string   `json:"scopes,omitempty"`
+	ClientID         string     `json:"client_id,omitempty"`
+	Current          bool       `json:"current"`
+	ExpiresAt        time.Time  `json:"expires_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at,omitempty"`
+}
+
+func (session Session) Active() bool {
+	return session.RevokedAt == nil && time.Now().Before(session.ExpiresAt)
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const maxTagLength = 100
+
+type TrendingTag struct {
+	Name       string  `json:"name"`
+	Score      float64 `json:"score"`
+	PostsCount uint64  `json:"posts_count"`
+}
+
+// ExtractTags finds the #hashtags in the texts, normalized and without
+// repetitions, in the order they first show up. A # only starts a hashtag
+// at the beginning of a word, so URL fragments and HTML entities are left
+// alone, and hashtags made only of digits, like #1, are ignored.
+func ExtractTags(texts ...string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, text := range texts {
+		runes := []rune(text)
+		for index := 0; index < len(runes); index++ {
+			if runes[index] != '#' || (index > 0 && !startsTag(runes[index-1])) {
+				continue
+			}
+
+			end := index + 1
+			for end < len(runes) && isTagRune(runes[end]) {
+				end++
+			}
+
+			tag, error := NormalizeTag(string(runes[index+1 : end]))
+			if error == nil && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+			index = end - 1
+		}
+	}
+	return tags
+}
+
+// NormalizeTag lower-cases the tag, with or without its leading #, failing
+// when it couldn't be a hashtag.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+
+	hasLetter := false
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", errors.New("tags can only have letters, digits and underscores")
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+	}
+	if !hasLetter {
+		return "", errors.New("tags must have a letter")
+	}
+	if len([]rune(tag)) > maxTagLength {
+		return "", errors.New("tags can't be longer than 100 characters")
+	}
+	return tag, nil
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+func startsTag(previous rune) bool {
+	return !isTagRune(previous) && !strings.ContainsRune("#&/", previous)
+}
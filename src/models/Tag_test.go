@@ -0,0 +1,59 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+	tests := []struct {
+		texts []string
+		want  []string
+	}{
+		{[]string{"no tags here"}, []string{}},
+		{[]string{"#Go and #golang, #go again"}, []string{"go", "golang"}},
+		{[]string{"#first", "then #second and #First"}, []string{"first", "second"}},
+		{[]string{"ending with #tag."}, []string{"tag"}},
+		{[]string{"(#paren) #snake_case #ação"}, []string{"paren", "snake_case", "ação"}},
+		{[]string{"#1 #2024 #web3"}, []string{"web3"}},
+		{[]string{"https://example.com/page#section"}, []string{}},
+		{[]string{"a&#39;b and ##double and a#b"}, []string{}},
+		{[]string{"#"}, []string{}},
+	}
+
+	for _, test := range tests {
+		if got := ExtractTags(test.texts...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ExtractTags(%q) = %q, want %q", test.texts, got, test.want)
+		}
+	}
+}
+
+func TestExtractTagsSkipsLongTags(t *testing.T) {
+	long := strings.Repeat("a", maxTagLength+1)
+	if got := ExtractTags("#" + long + " #short"); !reflect.DeepEqual(got, []string{"short"}) {
+		t.Errorf("ExtractTags = %q, want [short]", got)
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	valid := map[string]string{
+		"Go":                              "go",
+		"#GoLang":                         "golang",
+		"snake_case":                      "snake_case",
+		"Ação":                            "ação",
+		"web3":                            "web3",
+		strings.Repeat("a", maxTagLength): strings.Repeat("a", maxTagLength),
+	}
+	for tag, want := range valid {
+		if got, error := NormalizeTag(tag); error != nil || got != want {
+			t.Errorf("NormalizeTag(%q) = %q, %v, want %q", tag, got, error, want)
+		}
+	}
+
+	for _, tag := range []string{"", "#", "123", "two words", "dash-tag", "##go", strings.Repeat("a", maxTagLength+1)} {
+		if _, error := NormalizeTag(tag); error == nil {
+			t.Errorf("NormalizeTag(%q) accepted", tag)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
+}
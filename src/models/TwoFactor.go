@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type TwoFactor struct {
+	UserID    uint64
+	Secret    string
+	EnabledAt *time.Time
+	LastStep  int64
+}
+
+func (twoFactor TwoFactor) Enabled() bool {
+	return twoFactor.EnabledAt != nil
+}
+
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorCode struct {
+	Code     string `json:"code"`
+	Password string `json:"password,omitempty"`
+}
+
+func (code *TwoFactorCode) Prepare(requiresPassword bool) error {
+	code.Code = strings.ReplaceAll(strings.TrimSpace(code.Code), " ", "")
+	if code.Code == "" {
+		return errors.New("code required")
+	}
+	if requiresPassword && code.Password == "" {
+		return errors.New("password required")
+	}
+	return nil
+}
+
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	Code              string `json:"code,omitempty"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
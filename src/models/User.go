@@ -15,7 +15,11 @@ type User struct {
 	Nick      string    `json:"nick,omitempty"`
 	Email     string    `json:"email,omitempty"`
 	Password  string    `json:"password,omitempty"`
+	Role      string    `json:"role,omitempty"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PendingEmail    string     `json:"pending_email,omitempty"`
 }
 
 func (user *User) validate(step string) error {
@@ -25,6 +29,13 @@ func (user *User) validate(step string) error {
 	if step != "update-password" && user.Nick == "" {
 		return errors.New("nick required")
 	}
+	// Nicks taken before the current rules are kept, so updates only check
+	// the nick when it changes, through ValidateNick.
+	if step == "create" {
+		if error := user.ValidateNick(); error != nil {
+			return error
+		}
+	}
 	if step != "update-password" && user.Email == "" {
 		return errors.New("e-mail required")
 	}
@@ -33,8 +44,31 @@ func (user *User) validate(step string) error {
 			return errors.New("e-mail invalid")
 		}
 	}
-	if (step == "create" || step == "update-password") && user.Password == "" {
-		return errors.New("password required")
+	if step == "create" || step == "update-password" {
+		if user.Password == "" {
+			return errors.New("password required")
+		}
+		if error := security.ValidatePassword(user.Password, user.Nick, user.Email); error != nil {
+			return error
+		}
+	}
+	return nil
+}
+
+// ValidateNick only accepts nicks that can be mentioned as a whole, which
+// also keeps them from being taken for an e-mail when logging in.
+func (user User) ValidateNick() error {
+	nick := strings.TrimSpace(user.Nick)
+	for _, r := range nick {
+		if !isNickRune(r) {
+			return errors.New("nick can only have letters, digits, _, . and -")
+		}
+	}
+	if strings.HasSuffix(nick, ".") || strings.HasSuffix(nick, "-") {
+		return errors.New("nick can't end with . or -")
+	}
+	if len([]rune(nick)) > maxNickLength {
+		return errors.New("nick can't be longer than 50 characters")
 	}
 	return nil
 }
@@ -0,0 +1,17 @@
+package models
+
+import "testing"
+
+// Nicks taken before the current rules must not keep their owners from
+// updating the rest of the profile.
+func TestPrepareKeepsLegacyNicksOnUpdate(t *testing.T) {
+	user := User{Name: "Jane", Nick: "jane@home", Email: "jane@example.com"}
+	if error := user.Prepare("update"); error != nil {
+		t.Fatalf("update rejected: %v", error)
+	}
+
+	user = User{Name: "Jane", Nick: "jane@home", Email: "jane@example.com", Password: "correct horse battery staple"}
+	if error := user.Prepare("create"); error == nil {
+		t.Fatal("nick accepted on create")
+	}
+}
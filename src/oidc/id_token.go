@@ -0,0 +1,158 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"social-network/src/config"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type jwk struct {
+	KeyType  string `json:"kty"`
+	KeyID    string `json:"kid"`
+	Modulus  string `json:"n"`
+	Exponent string `json:"e"`
+	Curve    string `json:"crv"`
+	X        string `json:"x"`
+	Y        string `json:"y"`
+}
+
+// VerifyIDToken checks the signature of the ID token against the provider
+// keys and validates its issuer, audience, expiration and nonce.
+func VerifyIDToken(provider config.OIDCProvider, metadata Metadata, idToken, nonce string) (Claims, error) {
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if error := getJSON(metadata.JWKSURI, &jwks); error != nil {
+		return Claims{}, error
+	}
+
+	token, error := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		for _, key := range jwks.Keys {
+			if key.KeyID != keyID && keyID != "" {
+				continue
+			}
+			switch token.Method.(type) {
+			case *jwt.SigningMethodRSA:
+				if key.KeyType == "RSA" {
+					return rsaPublicKey(key)
+				}
+			case *jwt.SigningMethodECDSA:
+				if key.KeyType == "EC" {
+					return ecdsaPublicKey(key)
+				}
+			}
+		}
+		return nil, fmt.Errorf("no key found for %s", keyID)
+	})
+	if error != nil {
+		return Claims{}, error
+	}
+
+	permissions, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Claims{}, errors.New("invalid id token")
+	}
+
+	if !permissions.VerifyIssuer(metadata.Issuer, true) {
+		return Claims{}, errors.New("invalid id token issuer")
+	}
+	if !hasAudience(permissions["aud"], provider.ClientID) {
+		return Claims{}, errors.New("invalid id token audience")
+	}
+	if _, ok := permissions["exp"]; !ok {
+		return Claims{}, errors.New("id token without expiration")
+	}
+	if permissions["nonce"] != nonce {
+		return Claims{}, errors.New("invalid id token nonce")
+	}
+
+	claims := Claims{}
+	claims.Subject, _ = permissions["sub"].(string)
+	claims.Email, _ = permissions["email"].(string)
+	claims.Name, _ = permissions["name"].(string)
+	switch emailVerified := permissions["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = emailVerified
+	case string:
+		claims.EmailVerified = emailVerified == "true"
+	}
+
+	if claims.Subject == "" {
+		return Claims{}, errors.New("id token without subject")
+	}
+	return claims, nil
+}
+
+func hasAudience(audience interface{}, clientID string) bool {
+	switch audience := audience.(type) {
+	case string:
+		return audience == clientID
+	case []interface{}:
+		for _, value := range audience {
+			if value == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func rsaPublicKey(key jwk) (*rsa.PublicKey, error) {
+	modulus, error := base64.RawURLEncoding.DecodeString(key.Modulus)
+	if error != nil {
+		return nil, error
+	}
+	exponent, error := base64.RawURLEncoding.DecodeString(key.Exponent)
+	if error != nil {
+		return nil, error
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+func ecdsaPublicKey(key jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch key.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", key.Curve)
+	}
+
+	x, error := base64.RawURLEncoding.DecodeString(key.X)
+	if error != nil {
+		return nil, error
+	}
+	y, error := base64.RawURLEncoding.DecodeString(key.Y)
+	if error != nil {
+		return nil, error
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
@@ -0,0 +1,122 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"social-network/src/config"
+	"strings"
+	"time"
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Discover reads the provider metadata from its issuer, which also makes any
+// issuer reachable over plain HTTP usable, like a local mock for development.
+func Discover(issuer string) (Metadata, error) {
+	var metadata Metadata
+	if error := getJSON(strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &metadata); error != nil {
+		return Metadata{}, error
+	}
+
+	if metadata.Issuer != issuer {
+		return Metadata{}, fmt.Errorf("issuer mismatch: expected %s, got %s", issuer, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return Metadata{}, errors.New("incomplete provider metadata")
+	}
+	return metadata, nil
+}
+
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func AuthorizationURL(provider config.OIDCProvider, metadata Metadata, state, nonce, verifier string) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", provider.ClientID)
+	params.Set("redirect_uri", provider.RedirectURL)
+	params.Set("scope", "openid email profile")
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", CodeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange trades the authorization code for the ID token of the user.
+func Exchange(provider config.OIDCProvider, metadata Metadata, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.RedirectURL)
+	form.Set("client_id", provider.ClientID)
+	form.Set("code_verifier", verifier)
+
+	request, error := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if error != nil {
+		return "", error
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if provider.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+	}
+
+	response, error := client.Do(request)
+	if error != nil {
+		return "", error
+	}
+	defer response.Body.Close()
+
+	body, error := ioutil.ReadAll(response.Body)
+	if error != nil {
+		return "", error
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s", response.StatusCode, body)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if error := json.Unmarshal(body, &tokens); error != nil {
+		return "", error
+	}
+	if tokens.IDToken == "" {
+		return "", errors.New("token endpoint returned no id_token")
+	}
+	return tokens.IDToken, nil
+}
+
+func getJSON(address string, data interface{}) error {
+	response, error := client.Get(address)
+	if error != nil {
+		return error
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", address, response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(data)
+}
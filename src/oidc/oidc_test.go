@@ -0,0 +1,212 @@
+package oidc
+
+import (
+	"net/url"
+	"social-network/src/oidc/oidctest"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const redirectURL = "https://app.example.com/login/mock/callback"
+
+func TestDiscover(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "app")
+
+	metadata, error := Discover(issuer.URL)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if metadata.Issuer != issuer.URL || metadata.TokenEndpoint != issuer.URL+"/token" || metadata.JWKSURI != issuer.URL+"/jwks" {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+
+	// The issuer in the metadata must be the configured one, character by
+	// character.
+	if _, error := Discover(issuer.URL + "/"); error == nil {
+		t.Error("accepted the metadata of another issuer")
+	}
+	if _, error := Discover(issuer.URL + "/missing"); error == nil {
+		t.Error("accepted an issuer without metadata")
+	}
+}
+
+// The verifier and challenge of RFC 7636, appendix B.
+func TestCodeChallenge(t *testing.T) {
+	if got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallenge = %s", got)
+	}
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	provider := oidctest.NewIssuer(t, "app").Provider(redirectURL)
+	metadata := Metadata{AuthorizationEndpoint: "https://issuer.example.com/authorize?prompt=login"}
+
+	address, error := url.Parse(AuthorizationURL(provider, metadata, "state", "nonce", "verifier"))
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	want := map[string]string{
+		"prompt":                "login",
+		"response_type":         "code",
+		"client_id":             "app",
+		"redirect_uri":          redirectURL,
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        CodeChallenge("verifier"),
+		"code_challenge_method": "S256",
+	}
+	query := address.Query()
+	for param, value := range want {
+		if query.Get(param) != value {
+			t.Errorf("%s = %q, want %q", param, query.Get(param), value)
+		}
+	}
+	if query.Get("code_verifier") != "" {
+		t.Error("verifier sent to the authorization endpoint")
+	}
+	if !strings.Contains(query.Get("scope"), "openid") {
+		t.Errorf("scope = %q, want openid", query.Get("scope"))
+	}
+}
+
+// authorize signs in at the issuer and returns the code it redirected to.
+func authorize(t *testing.T, issuer *oidctest.Issuer, metadata Metadata, nonce, verifier string) string {
+	t.Helper()
+	callback, error := issuer.Authorize(AuthorizationURL(issuer.Provider(redirectURL), metadata, "state", nonce, verifier))
+	if error != nil {
+		t.Fatal(error)
+	}
+	if callback.Query().Get("state") != "state" {
+		t.Fatalf("state = %q, want state", callback.Query().Get("state"))
+	}
+	return callback.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "app")
+	provider := issuer.Provider(redirectURL)
+	metadata, error := Discover(issuer.URL)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	code := authorize(t, issuer, metadata, "nonce", "verifier")
+	idToken, error := Exchange(provider, metadata, code, "verifier")
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	claims, error := VerifyIDToken(provider, metadata, idToken, "nonce")
+	if error != nil {
+		t.Fatal(error)
+	}
+	if claims != (Claims{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"}) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if _, error := Exchange(provider, metadata, code, "verifier"); error == nil {
+		t.Error("exchanged the same code twice")
+	}
+}
+
+func TestExchangeRequiresCodeVerifier(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "app")
+	provider := issuer.Provider(redirectURL)
+	metadata, error := Discover(issuer.URL)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	for _, verifier := range []string{"another verifier", ""} {
+		code := authorize(t, issuer, metadata, "nonce", "verifier")
+		if _, error := Exchange(provider, metadata, code, verifier); error == nil {
+			t.Errorf("exchanged the code with verifier %q", verifier)
+		}
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "app")
+	provider := issuer.Provider(redirectURL)
+	metadata, error := Discover(issuer.URL)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	tests := []struct {
+		name   string
+		change func(claims jwt.MapClaims)
+		valid  bool
+	}{
+		{"valid", func(jwt.MapClaims) {}, true},
+		{"audience list", func(claims jwt.MapClaims) { claims["aud"] = []string{"other", "app"} }, true},
+		{"verified as string", func(claims jwt.MapClaims) { claims["email_verified"] = "true" }, true},
+		{"wrong nonce", func(claims jwt.MapClaims) { claims["nonce"] = "replayed" }, false},
+		{"no nonce", func(claims jwt.MapClaims) { delete(claims, "nonce") }, false},
+		{"wrong audience", func(claims jwt.MapClaims) { claims["aud"] = "other" }, false},
+		{"no audience", func(claims jwt.MapClaims) { delete(claims, "aud") }, false},
+		{"wrong issuer", func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }, false},
+		{"expired", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }, false},
+		{"no expiration", func(claims jwt.MapClaims) { delete(claims, "exp") }, false},
+		{"no subject", func(claims jwt.MapClaims) { delete(claims, "sub") }, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := issuer.IdentityClaims("nonce")
+			test.change(claims)
+
+			_, error := VerifyIDToken(provider, metadata, issuer.IDToken(claims), "nonce")
+			if test.valid && error != nil {
+				t.Fatalf("rejected: %v", error)
+			}
+			if !test.valid && error == nil {
+				t.Fatal("accepted")
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenChecksSignature(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "app")
+	provider := issuer.Provider(redirectURL)
+	metadata, error := Discover(issuer.URL)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	// Another issuer signs with another key, under the same key ID.
+	forged := oidctest.NewIssuer(t, "app").IDToken(issuer.IdentityClaims("nonce"))
+	if _, error := VerifyIDToken(provider, metadata, forged, "nonce"); error == nil {
+		t.Error("accepted a token signed by another key")
+	}
+
+	unsigned, error := jwt.NewWithClaims(jwt.SigningMethodNone, issuer.IdentityClaims("nonce")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if _, error := VerifyIDToken(provider, metadata, unsigned, "nonce"); error == nil {
+		t.Error("accepted an unsigned token")
+	}
+}
+
+func TestVerifyIDTokenUnverifiedEmail(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "app")
+	issuer.Identity.EmailVerified = false
+	metadata, error := Discover(issuer.URL)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	claims, error := VerifyIDToken(issuer.Provider(redirectURL), metadata, issuer.IDToken(issuer.IdentityClaims("nonce")), "nonce")
+	if error != nil {
+		t.Fatal(error)
+	}
+	if claims.EmailVerified {
+		t.Error("e-mail taken as verified")
+	}
+}
@@ -0,0 +1,287 @@
+// Package oidctest runs a mock OpenID Connect issuer, with its discovery
+// document, keys, authorization and token endpoints, to test the login
+// flow without a real identity provider.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"social-network/src/config"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const keyID = "oidctest"
+
+// Identity is the user the issuer signs in, whoever asks.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Issuer struct {
+	URL      string
+	ClientID string
+	Identity Identity
+	// Claims, when set, changes the claims of the ID tokens before they're
+	// signed, to issue tokens the client must reject.
+	Claims func(claims jwt.MapClaims)
+
+	key   *rsa.PrivateKey
+	mutex sync.Mutex
+	codes map[string]authorization
+}
+
+// authorization is what the issuer remembers of an authorization request
+// until its code is exchanged.
+type authorization struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// NewIssuer starts an issuer for the client, stopped when the test ends.
+func NewIssuer(t *testing.T, clientID string) *Issuer {
+	t.Helper()
+
+	key, error := rsa.GenerateKey(rand.Reader, 2048)
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	issuer := &Issuer{
+		ClientID: clientID,
+		Identity: Identity{
+			Subject:       "248289761001",
+			Email:         "jane@example.com",
+			EmailVerified: true,
+			Name:          "Jane Doe",
+		},
+		key:   key,
+		codes: map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	mux.HandleFunc("/authorize", issuer.authorize)
+	mux.HandleFunc("/token", issuer.token)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	issuer.URL = server.URL
+	return issuer
+}
+
+// Provider configures the application as the client of the issuer.
+func (issuer *Issuer) Provider(redirectURL string) config.OIDCProvider {
+	return config.OIDCProvider{
+		Issuer:      issuer.URL,
+		ClientID:    issuer.ClientID,
+		RedirectURL: redirectURL,
+	}
+}
+
+// Authorize plays the browser of the user, who signs in at the issuer, and
+// returns the callback the issuer redirects them to.
+func (issuer *Issuer) Authorize(authorizationURL string) (*url.URL, error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, error := client.Get(authorizationURL)
+	if error != nil {
+		return nil, error
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("authorization endpoint returned %d", response.StatusCode)
+	}
+	return response.Location()
+}
+
+// IDToken signs the claims with the key of the issuer.
+func (issuer *Issuer) IDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+
+	idToken, error := token.SignedString(issuer.key)
+	if error != nil {
+		panic(error)
+	}
+	return idToken
+}
+
+// IdentityClaims returns the claims of an ID token issued for the nonce.
+func (issuer *Issuer) IdentityClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            issuer.URL,
+		"sub":            issuer.Identity.Subject,
+		"aud":            issuer.ClientID,
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          issuer.Identity.Email,
+		"email_verified": issuer.Identity.EmailVerified,
+		"name":           issuer.Identity.Name,
+	}
+}
+
+func (issuer *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 issuer.URL,
+		"authorization_endpoint": issuer.URL + "/authorize",
+		"token_endpoint":         issuer.URL + "/token",
+		"jwks_uri":               issuer.URL + "/jwks",
+	})
+}
+
+func (issuer *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	publicKey := issuer.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+func (issuer *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case query.Get("response_type") != "code":
+		http.Error(w, "unsupported response_type", http.StatusBadRequest)
+		return
+	case query.Get("client_id") != issuer.ClientID:
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	case query.Get("redirect_uri") == "":
+		http.Error(w, "redirect_uri required", http.StatusBadRequest)
+		return
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		http.Error(w, "S256 code_challenge required", http.StatusBadRequest)
+		return
+	}
+
+	code, error := randomString()
+	if error != nil {
+		http.Error(w, error.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	issuer.mutex.Lock()
+	issuer.codes[code] = authorization{
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	issuer.mutex.Unlock()
+
+	redirect, error := url.Parse(query.Get("redirect_uri"))
+	if error != nil {
+		http.Error(w, error.Error(), http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token exchanges each code a single time, and only for the verifier of the
+// challenge it was issued for.
+func (issuer *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if error := r.ParseForm(); error != nil {
+		tokenError(w, "invalid_request", error)
+		return
+	}
+
+	issuer.mutex.Lock()
+	code := r.PostForm.Get("code")
+	authorization, ok := issuer.codes[code]
+	delete(issuer.codes, code)
+	issuer.mutex.Unlock()
+
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code":
+		tokenError(w, "unsupported_grant_type", errors.New("only authorization_code is supported"))
+		return
+	case r.PostForm.Get("client_id") != issuer.ClientID:
+		tokenError(w, "invalid_client", errors.New("unknown client_id"))
+		return
+	case !ok:
+		tokenError(w, "invalid_grant", errors.New("unknown or used code"))
+		return
+	case r.PostForm.Get("redirect_uri") != authorization.redirectURI:
+		tokenError(w, "invalid_grant", errors.New("redirect_uri mismatch"))
+		return
+	case codeChallenge(r.PostForm.Get("code_verifier")) != authorization.codeChallenge:
+		tokenError(w, "invalid_grant", errors.New("code_verifier doesn't match the code_challenge"))
+		return
+	}
+
+	claims := issuer.IdentityClaims(authorization.nonce)
+	if issuer.Claims != nil {
+		issuer.Claims(claims)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": code,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     issuer.IDToken(claims),
+	})
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString() (string, error) {
+	bytes := make([]byte, 16)
+	if _, error := rand.Read(bytes); error != nil {
+		return "", error
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func tokenError(w http.ResponseWriter, code string, error error) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": error.Error(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
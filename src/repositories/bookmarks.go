@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type bookmarks struct {
+	db *sql.DB
+}
+
+func NewRepositoryBookmarks(db *sql.DB) *bookmarks {
+	return &bookmarks{db}
+}
+
+// Add bookmarks the post, or moves it to the given collection when it was
+// already bookmarked.
+func (repositoryBookmarks bookmarks) Add(userID, postID uint64, collectionID *uint64) error {
+	statement, error := repositoryBookmarks.db.Prepare(`
+		insert into bookmarks (user_id, post_id, collection_id) values (?, ?, ?)
+		on duplicate key update collection_id = values(collection_id)
+	`)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(userID, postID, collectionID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryBookmarks bookmarks) Remove(userID, postID uint64) error {
+	statement, error := repositoryBookmarks.db.Prepare("delete from bookmarks where user_id = ? and post_id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(userID, postID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryBookmarks bookmarks) CreateCollection(collection models.BookmarkCollection) (uint64, error) {
+	statement, error := repositoryBookmarks.db.Prepare("insert into bookmark_collections (user_id, name) values (?, ?)")
+	if error != nil {
+		return 0, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(collection.UserID, collection.Name)
+	if error != nil {
+		return 0, error
+	}
+
+	lastID, error := result.LastInsertId()
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastID), nil
+}
+
+// GetCollection only finds collections of the given user.
+func (repositoryBookmarks bookmarks) GetCollection(ID, userID uint64) (models.BookmarkCollection, error) {
+	line, error := repositoryBookmarks.db.Query(`
+		select c.id, c.user_id, c.name, (select count(*) from bookmarks b where b.collection_id = c.id), c.created_at
+		from bookmark_collections c
+		where c.id = ? and c.user_id = ?
+		`,
+		ID,
+		userID,
+	)
+	if error != nil {
+		return models.BookmarkCollection{}, error
+	}
+	defer line.Close()
+
+	var collection models.BookmarkCollection
+	if line.Next() {
+		if error := line.Scan(
+			&collection.ID,
+			&collection.UserID,
+			&collection.Name,
+			&collection.BookmarksCount,
+			&collection.CreatedAt,
+		); error != nil {
+			return models.BookmarkCollection{}, error
+		}
+	}
+	return collection, nil
+}
+
+func (repositoryBookmarks bookmarks) ListCollections(userID uint64) ([]models.BookmarkCollection, error) {
+	lines, error := repositoryBookmarks.db.Query(`
+		select c.id, c.user_id, c.name, (select count(*) from bookmarks b where b.collection_id = c.id), c.created_at
+		from bookmark_collections c
+		where c.user_id = ?
+		order by c.name
+		`,
+		userID,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	collections := []models.BookmarkCollection{}
+	for lines.Next() {
+		var collection models.BookmarkCollection
+		if error := lines.Scan(
+			&collection.ID,
+			&collection.UserID,
+			&collection.Name,
+			&collection.BookmarksCount,
+			&collection.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+		collections = append(collections, collection)
+	}
+
+	return collections, nil
+}
+
+func (repositoryBookmarks bookmarks) UpdateCollection(ID, userID uint64, name string) error {
+	statement, error := repositoryBookmarks.db.Prepare("update bookmark_collections set name = ? where id = ? and user_id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(name, ID, userID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// DeleteCollection keeps the bookmarks filed in it, just without a
+// collection.
+func (repositoryBookmarks bookmarks) DeleteCollection(ID, userID uint64) error {
+	statement, error := repositoryBookmarks.db.Prepare("delete from bookmark_collections where id = ? and user_id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(ID, userID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type comments struct {
+	db *sql.DB
+}
+
+func NewRepositoryComments(db *sql.DB) *comments {
+	return &comments{db}
+}
+
+func (repositoryComments comments) Create(comment models.Comment) (uint64, error) {
+	statement, error := repositoryComments.db.Prepare(
+		"insert into comments (post_id, author_id, content) values (?, ?, ?)",
+	)
+	if error != nil {
+		return 0, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(comment.PostID, comment.AuthorID, comment.Content)
+	if error != nil {
+		return 0, error
+	}
+
+	lastID, error := result.LastInsertId()
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastID), nil
+}
+
+func (repositoryComments comments) GetComment(ID uint64) (models.Comment, error) {
+	line, error := repositoryComments.db.Query(`
+		select c.id, c.post_id, c.author_id, u.nick, c.content, c.created_at, c.updated_at from comments c
+			inner join users u on u.id = c.author_id
+		where c.id = ?
+		`,
+		ID,
+	)
+	if error != nil {
+		return models.Comment{}, error
+	}
+	defer line.Close()
+
+	var comment models.Comment
+	if line.Next() {
+		if error := line.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.AuthorNick,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		); error != nil {
+			return models.Comment{}, error
+		}
+	}
+	return comment, nil
+}
+
+func (repositoryComments comments) ListForPost(postID uint64) ([]models.Comment, error) {
+	lines, error := repositoryComments.db.Query(`
+		select c.id, c.post_id, c.author_id, u.nick, c.content, c.created_at, c.updated_at from comments c
+			inner join users u on u.id = c.author_id
+		where c.post_id = ?
+		order by c.id
+		`,
+		postID,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	comments := []models.Comment{}
+	for lines.Next() {
+		var comment models.Comment
+		if error := lines.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.AuthorNick,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		); error != nil {
+			return nil, error
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+func (repositoryComments comments) UpdateComment(ID uint64, content string) error {
+	statement, error := repositoryComments.db.Prepare(
+		"update comments set content = ?, updated_at = current_timestamp() where id = ?",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(content, ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryComments comments) DeleteComment(ID uint64) error {
+	statement, error := repositoryComments.db.Prepare("delete from comments where id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(ID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type emailVerifications struct {
+	db *sql.DB
+}
+
+func NewRepositoryEmailVerifications(db *sql.DB) *emailVerifications {
+	return &emailVerifications{db}
+}
+
+func (repositoryEmailVerifications emailVerifications) Create(emailVerification models.EmailVerification) (uint64, error) {
+	statement, error := repositoryEmailVerifications.db.Prepare(
+		"insert into email_verifications (user_id, email, token_hash, expires_at) values (?, ?, ?, ?)",
+	)
+	if error != nil {
+		return 0, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(
+		emailVerification.UserID,
+		emailVerification.Email,
+		emailVerification.TokenHash,
+		emailVerification.ExpiresAt,
+	)
+	if error != nil {
+		return 0, error
+	}
+
+	lastIDInserted, error := result.LastInsertId()
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastIDInserted), nil
+}
+
+func (repositoryEmailVerifications emailVerifications) GetForTokenHash(tokenHash string) (models.EmailVerification, error) {
+	line, error := repositoryEmailVerifications.db.Query(
+		"select id, user_id, email, token_hash, expires_at, used_at, created_at from email_verifications where token_hash = ?",
+		tokenHash,
+	)
+	if error != nil {
+		return models.EmailVerification{}, error
+	}
+	defer line.Close()
+
+	var emailVerification models.EmailVerification
+	if line.Next() {
+		if error := line.Scan(
+			&emailVerification.ID,
+			&emailVerification.UserID,
+			&emailVerification.Email,
+			&emailVerification.TokenHash,
+			&emailVerification.ExpiresAt,
+			&emailVerification.UsedAt,
+			&emailVerification.CreatedAt,
+		); error != nil {
+			return models.EmailVerification{}, error
+		}
+	}
+	return emailVerification, nil
+}
+
+// Confirm consumes the verification and marks its address as the verified
+// e-mail of the user, replacing the previous one when it was an e-mail change.
+// It fails with a duplicate entry error when another user took the address
+// in the meantime.
+func (repositoryEmailVerifications emailVerifications) Confirm(emailVerification models.EmailVerification) (bool, error) {
+	transaction, error := repositoryEmailVerifications.db.Begin()
+	if error != nil {
+		return false, error
+	}
+	defer transaction.Rollback()
+
+	result, error := transaction.Exec(
+		"update email_verifications set used_at = current_timestamp() where id = ? and used_at is null",
+		emailVerification.ID,
+	)
+	if error != nil {
+		return false, error
+	}
+	if rowsAffected, error := result.RowsAffected(); error != nil {
+		return false, error
+	} else if rowsAffected != 1 {
+		return false, nil
+	}
+
+	// The address must still be the one being verified: a verification of an
+	// address the user has since replaced is no longer valid.
+	result, error = transaction.Exec(`
+		update users set
+			email = ?,
+			pending_email = if(pending_email = ?, null, pending_email),
+			email_verified_at = current_timestamp()
+		where id = ? and (pending_email = ? or email = ?)
+		`,
+		emailVerification.Email,
+		emailVerification.Email,
+		emailVerification.UserID,
+		emailVerification.Email,
+		emailVerification.Email,
+	)
+	if error != nil {
+		return false, error
+	}
+	if rowsAffected, error := result.RowsAffected(); error != nil {
+		return false, error
+	} else if rowsAffected != 1 {
+		return false, nil
+	}
+
+	if _, error := transaction.Exec(
+		"update email_verifications set used_at = current_timestamp() where user_id = ? and email = ? and used_at is null",
+		emailVerification.UserID,
+		emailVerification.Email,
+	); error != nil {
+		return false, error
+	}
+
+	return true, transaction.Commit()
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/lockout"
+	"time"
+)
+
+type loginAttempts struct {
+	db *sql.DB
+}
+
+func NewRepositoryLoginAttempts(db *sql.DB) *loginAttempts {
+	return &loginAttempts{db}
+}
+
+func (repositoryLoginAttempts loginAttempts) Get(key string) (lockout.Attempts, error) {
+	line, error := repositoryLoginAttempts.db.Query(
+		"select attempt_key, failures, last_failure_at, locked_until from login_attempts where attempt_key = ?",
+		key,
+	)
+	if error != nil {
+		return lockout.Attempts{}, error
+	}
+	defer line.Close()
+
+	attempts := lockout.Attempts{Key: key}
+	if line.Next() {
+		if error := line.Scan(
+			&attempts.Key,
+			&attempts.Failures,
+			&attempts.LastFailureAt,
+			&attempts.LockedUntil,
+		); error != nil {
+			return lockout.Attempts{}, error
+		}
+	}
+	return attempts, nil
+}
+
+// RegisterFailure locks the row while the policy is applied, so concurrent
+// API instances can't lose each other's failures.
+func (repositoryLoginAttempts loginAttempts) RegisterFailure(key string, policy lockout.Policy, now time.Time) (lockout.Attempts, error) {
+	transaction, error := repositoryLoginAttempts.db.Begin()
+	if error != nil {
+		return lockout.Attempts{}, error
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec(`
+		insert ignore into login_attempts (attempt_key, failures, last_failure_at, locked_until)
+		values (?, 0, '1970-01-01 00:00:01', '1970-01-01 00:00:01')
+		`,
+		key,
+	); error != nil {
+		return lockout.Attempts{}, error
+	}
+
+	attempts := lockout.Attempts{Key: key}
+	if error := transaction.QueryRow(
+		"select failures, last_failure_at, locked_until from login_attempts where attempt_key = ? for update",
+		key,
+	).Scan(
+		&attempts.Failures,
+		&attempts.LastFailureAt,
+		&attempts.LockedUntil,
+	); error != nil {
+		return lockout.Attempts{}, error
+	}
+
+	attempts = policy.Fail(attempts, now)
+
+	if _, error := transaction.Exec(
+		"update login_attempts set failures = ?, last_failure_at = ?, locked_until = ? where attempt_key = ?",
+		attempts.Failures,
+		attempts.LastFailureAt,
+		attempts.LockedUntil,
+		key,
+	); error != nil {
+		return lockout.Attempts{}, error
+	}
+
+	return attempts, transaction.Commit()
+}
+
+func (repositoryLoginAttempts loginAttempts) Reset(key string) error {
+	statement, error := repositoryLoginAttempts.db.Prepare("delete from login_attempts where attempt_key = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(key); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryLoginAttempts loginAttempts) Audit(record lockout.Lockout) error {
+	statement, error := repositoryLoginAttempts.db.Prepare(
+		"insert into lockout_audits (attempt_key, ip_address, failures, locked_until) values (?, ?, ?, ?)",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(record.Key, record.IPAddress, record.Failures, record.LockedUntil); error != nil {
+		return error
+	}
+
+	return nil
+}
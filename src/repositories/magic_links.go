@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type magicLinks struct {
+	db *sql.DB
+}
+
+func NewRepositoryMagicLinks(db *sql.DB) *magicLinks {
+	return &magicLinks{db}
+}
+
+func (repositoryMagicLinks magicLinks) Create(magicLink models.MagicLink) error {
+	statement, error := repositoryMagicLinks.db.Prepare(
+		"insert into magic_links (user_id, email, link_id_hash, expires_at) values (?, ?, ?, ?)",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(
+		magicLink.UserID,
+		magicLink.Email,
+		magicLink.LinkIDHash,
+		magicLink.ExpiresAt,
+	); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryMagicLinks magicLinks) GetForLinkIDHash(linkIDHash string) (models.MagicLink, error) {
+	line, error := repositoryMagicLinks.db.Query(
+		"select id, user_id, email, link_id_hash, expires_at, used_at from magic_links where link_id_hash = ?",
+		linkIDHash,
+	)
+	if error != nil {
+		return models.MagicLink{}, error
+	}
+	defer line.Close()
+
+	var magicLink models.MagicLink
+	if line.Next() {
+		if error := line.Scan(
+			&magicLink.ID,
+			&magicLink.UserID,
+			&magicLink.Email,
+			&magicLink.LinkIDHash,
+			&magicLink.ExpiresAt,
+			&magicLink.UsedAt,
+		); error != nil {
+			return models.MagicLink{}, error
+		}
+	}
+	return magicLink, nil
+}
+
+// Use marks the link as consumed, failing when it was used before.
+func (repositoryMagicLinks magicLinks) Use(ID uint64) (bool, error) {
+	statement, error := repositoryMagicLinks.db.Prepare(
+		"update magic_links set used_at = current_timestamp() where id = ? and used_at is null",
+	)
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(ID)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+	"strings"
+)
+
+// setPostMentions replaces the mentions of the post with the ones of users
+// that exist, notifying who wasn't mentioned in it yet unless they blocked
+// the author. Users no longer mentioned lose their notification.
+func setPostMentions(transaction *sql.Tx, postID, authorID uint64, mentions []models.Mention) error {
+	if _, error := transaction.Exec("delete from post_mentions where post_id = ?", postID); error != nil {
+		return error
+	}
+
+	if len(mentions) > 0 {
+		userIDs, error := resolveNicks(transaction, mentions)
+		if error != nil {
+			return error
+		}
+
+		var (
+			values []string
+			args   []interface{}
+		)
+		for _, mention := range mentions {
+			userID, ok := userIDs[strings.ToLower(mention.Nick)]
+			if !ok {
+				continue
+			}
+			values = append(values, "(?, ?, ?, ?, ?, ?)")
+			args = append(args, postID, mention.Field, mention.Start, mention.End, mention.Nick, userID)
+		}
+
+		if len(values) > 0 {
+			if _, error := transaction.Exec(
+				"insert into post_mentions (post_id, field, start_offset, end_offset, nick, user_id) values "+strings.Join(values, ", "),
+				args...,
+			); error != nil {
+				return error
+			}
+		}
+	}
+
+	if _, error := transaction.Exec(`
+		delete from notifications
+		where kind = ? and post_id = ?
+			and user_id not in (select user_id from post_mentions where post_id = ?)
+		`,
+		models.NotificationMention,
+		postID,
+		postID,
+	); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec(`
+		insert ignore into notifications (user_id, kind, actor_id, post_id)
+		select distinct m.user_id, ?, ?, m.post_id from post_mentions m
+		where m.post_id = ? and m.user_id <> ?
+			and not exists (select 1 from user_blocks b where b.blocker_id = m.user_id and b.blocked_id = ?)
+		`,
+		models.NotificationMention,
+		authorID,
+		postID,
+		authorID,
+		authorID,
+	); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// resolveNicks finds the users mentioned, by their lower-cased nick, as nicks
+// are unique regardless of case.
+func resolveNicks(transaction *sql.Tx, mentions []models.Mention) (map[string]uint64, error) {
+	nicks := make([]interface{}, 0, len(mentions))
+	for _, mention := range mentions {
+		nicks = append(nicks, mention.Nick)
+	}
+
+	lines, error := transaction.Query("select id, nick from users where nick in ("+placeholders(len(nicks))+")", nicks...)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	userIDs := map[string]uint64{}
+	for lines.Next() {
+		var (
+			userID uint64
+			nick   string
+		)
+		if error := lines.Scan(&userID, &nick); error != nil {
+			return nil, error
+		}
+		userIDs[strings.ToLower(nick)] = userID
+	}
+
+	return userIDs, lines.Err()
+}
+
+// loadMentions fills the mentions of a page of posts. Mentions of deleted
+// users are gone, leaving the @nick as plain text.
+func loadMentions(db *sql.DB, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	indexes := map[uint64][]int{}
+	args := make([]interface{}, 0, len(posts))
+	for index := range posts {
+		posts[index].Mentions = []models.Mention{}
+		if _, ok := indexes[posts[index].ID]; !ok {
+			args = append(args, posts[index].ID)
+		}
+		indexes[posts[index].ID] = append(indexes[posts[index].ID], index)
+	}
+
+	lines, error := db.Query(`
+		select m.post_id, m.field, m.start_offset, m.end_offset, m.nick, u.id, u.nick from post_mentions m
+			inner join users u on u.id = m.user_id
+		where m.post_id in (`+placeholders(len(args))+`)
+		order by m.post_id, m.field = 'content', m.start_offset
+		`,
+		args...,
+	)
+	if error != nil {
+		return error
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var (
+			postID  uint64
+			mention models.Mention
+		)
+		if error := lines.Scan(
+			&postID,
+			&mention.Field,
+			&mention.Start,
+			&mention.End,
+			&mention.Nick,
+			&mention.UserID,
+			&mention.UserNick,
+		); error != nil {
+			return error
+		}
+
+		for _, index := range indexes[postID] {
+			posts[index].Mentions = append(posts[index].Mentions, mention)
+		}
+	}
+
+	return lines.Err()
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type notifications struct {
+	db *sql.DB
+}
+
+func NewRepositoryNotifications(db *sql.DB) *notifications {
+	return &notifications{db}
+}
+
+// List returns the notifications of the user, the latest first, optionally
+// only the unread ones.
+func (repositoryNotifications notifications) List(userID uint64, unreadOnly bool, limit, offset uint64) ([]models.Notification, error) {
+	lines, error := repositoryNotifications.db.Query(`
+		select n.id, n.kind, u.id, u.name, u.nick, coalesce(n.post_id, 0), n.read_at is not null, n.created_at
+		from notifications n
+			inner join users u on u.id = n.actor_id
+		where n.user_id = ? and (not ? or n.read_at is null)
+		order by n.created_at desc, n.id desc
+		limit ? offset ?
+		`,
+		userID,
+		unreadOnly,
+		limit,
+		offset,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	notifications := []models.Notification{}
+	for lines.Next() {
+		var notification models.Notification
+		if error := lines.Scan(
+			&notification.ID,
+			&notification.Kind,
+			&notification.Actor.ID,
+			&notification.Actor.Name,
+			&notification.Actor.Nick,
+			&notification.PostID,
+			&notification.Read,
+			&notification.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+func (repositoryNotifications notifications) MarkAllRead(userID uint64) error {
+	statement, error := repositoryNotifications.db.Prepare("update notifications set read_at = now() where user_id = ? and read_at is null")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(userID); error != nil {
+		return error
+	}
+
+	return nil
+}
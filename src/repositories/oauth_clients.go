@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+	"strings"
+)
+
+type oauthClients struct {
+	db *sql.DB
+}
+
+func NewRepositoryOAuthClients(db *sql.DB) *oauthClients {
+	return &oauthClients{db}
+}
+
+func (repositoryClients oauthClients) Create(client models.OAuthClient) (uint64, error) {
+	statement, error := repositoryClients.db.Prepare(`
+		insert into oauth_clients (client_id, client_secret_hash, name, redirect_uris, scopes, owner_id)
+		values (?, nullif(?, ''), ?, ?, ?, ?)
+	`)
+	if error != nil {
+		return 0, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(
+		client.ClientID,
+		client.ClientSecretHash,
+		client.Name,
+		strings.Join(client.RedirectURIs, " "),
+		strings.Join(client.Scopes, " "),
+		client.OwnerID,
+	)
+	if error != nil {
+		return 0, error
+	}
+
+	lastIDInserted, error := result.LastInsertId()
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastIDInserted), nil
+}
+
+func (repositoryClients oauthClients) GetForClientID(clientID string) (models.OAuthClient, error) {
+	line, error := repositoryClients.db.Query(`
+		select id, client_id, coalesce(client_secret_hash, ''), name, redirect_uris, scopes, owner_id, created_at
+			from oauth_clients where client_id = ?
+		`,
+		clientID,
+	)
+	if error != nil {
+		return models.OAuthClient{}, error
+	}
+	defer line.Close()
+
+	var client models.OAuthClient
+	if line.Next() {
+		var redirectURIs, scopes string
+		if error := line.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.ClientSecretHash,
+			&client.Name,
+			&redirectURIs,
+			&scopes,
+			&client.OwnerID,
+			&client.CreatedAt,
+		); error != nil {
+			return models.OAuthClient{}, error
+		}
+		client.RedirectURIs = strings.Fields(redirectURIs)
+		client.Scopes = strings.Fields(scopes)
+		client.Confidential = client.ClientSecretHash != ""
+	}
+	return client, nil
+}
+
+func (repositoryClients oauthClients) ListForOwner(ownerID uint64) ([]models.OAuthClient, error) {
+	lines, error := repositoryClients.db.Query(`
+		select id, client_id, coalesce(client_secret_hash, ''), name, redirect_uris, scopes, owner_id, created_at
+			from oauth_clients where owner_id = ?
+		order by created_at desc
+		`,
+		ownerID,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	var clients []models.OAuthClient
+	for lines.Next() {
+		var client models.OAuthClient
+		var redirectURIs, scopes string
+		if error := lines.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.ClientSecretHash,
+			&client.Name,
+			&redirectURIs,
+			&scopes,
+			&client.OwnerID,
+			&client.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+		client.RedirectURIs = strings.Fields(redirectURIs)
+		client.Scopes = strings.Fields(scopes)
+		client.Confidential = client.ClientSecretHash != ""
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+func (repositoryClients oauthClients) Delete(ID, ownerID uint64) (bool, error) {
+	statement, error := repositoryClients.db.Prepare("delete from oauth_clients where id = ? and owner_id = ?")
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(ID, ownerID)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
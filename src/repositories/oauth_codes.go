@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+	"strings"
+)
+
+type oauthCodes struct {
+	db *sql.DB
+}
+
+func NewRepositoryOAuthCodes(db *sql.DB) *oauthCodes {
+	return &oauthCodes{db}
+}
+
+func (repositoryCodes oauthCodes) Create(code models.OAuthAuthorizationCode) error {
+	statement, error := repositoryCodes.db.Prepare(`
+		insert into oauth_authorization_codes
+			(code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method, expires_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(
+		code.CodeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		strings.Join(code.Scopes, " "),
+		code.CodeChallenge,
+		code.CodeChallengeMethod,
+		code.ExpiresAt,
+	); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryCodes oauthCodes) GetForCodeHash(codeHash string) (models.OAuthAuthorizationCode, error) {
+	line, error := repositoryCodes.db.Query(`
+		select id, code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method,
+			coalesce(session_id, 0), expires_at, used_at
+		from oauth_authorization_codes where code_hash = ?
+		`,
+		codeHash,
+	)
+	if error != nil {
+		return models.OAuthAuthorizationCode{}, error
+	}
+	defer line.Close()
+
+	var code models.OAuthAuthorizationCode
+	if line.Next() {
+		var scopes string
+		if error := line.Scan(
+			&code.ID,
+			&code.CodeHash,
+			&code.ClientID,
+			&code.UserID,
+			&code.RedirectURI,
+			&scopes,
+			&code.CodeChallenge,
+			&code.CodeChallengeMethod,
+			&code.SessionID,
+			&code.ExpiresAt,
+			&code.UsedAt,
+		); error != nil {
+			return models.OAuthAuthorizationCode{}, error
+		}
+		code.Scopes = strings.Fields(scopes)
+	}
+	return code, nil
+}
+
+// Use marks the code as exchanged, failing when it was exchanged before.
+func (repositoryCodes oauthCodes) Use(ID uint64) (bool, error) {
+	statement, error := repositoryCodes.db.Prepare(
+		"update oauth_authorization_codes set used_at = current_timestamp() where id = ? and used_at is null",
+	)
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(ID)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (repositoryCodes oauthCodes) SetSession(ID, sessionID uint64) error {
+	statement, error := repositoryCodes.db.Prepare("update oauth_authorization_codes set session_id = ? where id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(sessionID, ID); error != nil {
+		return error
+	}
+
+	return nil
+}
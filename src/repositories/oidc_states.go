@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type oidcStates struct {
+	db *sql.DB
+}
+
+func NewRepositoryOIDCStates(db *sql.DB) *oidcStates {
+	return &oidcStates{db}
+}
+
+func (repositoryStates oidcStates) Create(state models.OIDCState) error {
+	statement, error := repositoryStates.db.Prepare(
+		"insert into oidc_states (state_hash, provider, code_verifier, nonce, expires_at) values (?, ?, ?, ?, ?)",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(
+		state.StateHash,
+		state.Provider,
+		state.CodeVerifier,
+		state.Nonce,
+		state.ExpiresAt,
+	); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// Consume returns the state and deletes it, so each one is used a single time.
+func (repositoryStates oidcStates) Consume(stateHash string) (models.OIDCState, error) {
+	transaction, error := repositoryStates.db.Begin()
+	if error != nil {
+		return models.OIDCState{}, error
+	}
+	defer transaction.Rollback()
+
+	var state models.OIDCState
+	if error := transaction.QueryRow(`
+		select id, state_hash, provider, code_verifier, nonce, expires_at from oidc_states
+		where state_hash = ? for update
+		`,
+		stateHash,
+	).Scan(
+		&state.ID,
+		&state.StateHash,
+		&state.Provider,
+		&state.CodeVerifier,
+		&state.Nonce,
+		&state.ExpiresAt,
+	); error != nil {
+		if error == sql.ErrNoRows {
+			return models.OIDCState{}, nil
+		}
+		return models.OIDCState{}, error
+	}
+
+	if _, error := transaction.Exec(
+		"delete from oidc_states where id = ? or expires_at < now()",
+		state.ID,
+	); error != nil {
+		return models.OIDCState{}, error
+	}
+
+	return state, transaction.Commit()
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type passwordResets struct {
+	db *sql.DB
+}
+
+func NewRepositoryPasswordResets(db *sql.DB) *passwordResets {
+	return &passwordResets{db}
+}
+
+func (repositoryPasswordResets passwordResets) Create(passwordReset models.PasswordReset) (uint64, error) {
+	statement, error := repositoryPasswordResets.db.Prepare(
+		"insert into password_resets (user_id, token_hash, expires_at) values (?, ?, ?)",
+	)
+	if error != nil {
+		return 0, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(passwordReset.UserID, passwordReset.TokenHash, passwordReset.ExpiresAt)
+	if error != nil {
+		return 0, error
+	}
+
+	lastIDInserted, error := result.LastInsertId()
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastIDInserted), nil
+}
+
+func (repositoryPasswordResets passwordResets) GetForTokenHash(tokenHash string) (models.PasswordReset, error) {
+	line, error := repositoryPasswordResets.db.Query(
+		"select id, user_id, token_hash, expires_at, used_at, created_at from password_resets where token_hash = ?",
+		tokenHash,
+	)
+	if error != nil {
+		return models.PasswordReset{}, error
+	}
+	defer line.Close()
+
+	var passwordReset models.PasswordReset
+	if line.Next() {
+		if error := line.Scan(
+			&passwordReset.ID,
+			&passwordReset.UserID,
+			&passwordReset.TokenHash,
+			&passwordReset.ExpiresAt,
+			&passwordReset.UsedAt,
+			&passwordReset.CreatedAt,
+		); error != nil {
+			return models.PasswordReset{}, error
+		}
+	}
+	return passwordReset, nil
+}
+
+// Use marks the reset as consumed, failing when another request already used it.
+func (repositoryPasswordResets passwordResets) Use(ID uint64) (bool, error) {
+	statement, error := repositoryPasswordResets.db.Prepare(
+		"update password_resets set used_at = current_timestamp() where id = ? and used_at is null",
+	)
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(ID)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (repositoryPasswordResets passwordResets) InvalidateForUser(userID uint64) error {
+	statement, error := repositoryPasswordResets.db.Prepare(
+		"update password_resets set used_at = current_timestamp() where user_id = ? and used_at is null",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(userID); error != nil {
+		return error
+	}
+
+	return nil
+}
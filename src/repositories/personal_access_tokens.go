@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+	"strings"
+)
+
+type personalAccessTokens struct {
+	db *sql.DB
+}
+
+func NewRepositoryPersonalAccessTokens(db *sql.DB) *personalAccessTokens {
+	return &personalAccessTokens{db}
+}
+
+func (repositoryTokens personalAccessTokens) Create(token models.PersonalAccessToken) (uint64, error) {
+	statement, error := repositoryTokens.db.Prepare(
+		"insert into personal_access_tokens (user_id, name, token_hash, scopes, expires_at) values (?, ?, ?, ?, ?)",
+	)
+	if error != nil {
+		return 0, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		strings.Join(token.Scopes, " "),
+		token.ExpiresAt,
+	)
+	if error != nil {
+		return 0, error
+	}
+
+	lastIDInserted, error := result.LastInsertId()
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastIDInserted), nil
+}
+
+func (repositoryTokens personalAccessTokens) GetForTokenHash(tokenHash string) (models.PersonalAccessToken, error) {
+	line, error := repositoryTokens.db.Query(`
+		select id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at
+			from personal_access_tokens where token_hash = ?
+		`,
+		tokenHash,
+	)
+	if error != nil {
+		return models.PersonalAccessToken{}, error
+	}
+	defer line.Close()
+
+	var token models.PersonalAccessToken
+	var scopes string
+	if line.Next() {
+		if error := line.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&scopes,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.RevokedAt,
+			&token.CreatedAt,
+		); error != nil {
+			return models.PersonalAccessToken{}, error
+		}
+	}
+	token.Scopes = strings.Fields(scopes)
+	return token, nil
+}
+
+func (repositoryTokens personalAccessTokens) List(userID uint64) ([]models.PersonalAccessToken, error) {
+	lines, error := repositoryTokens.db.Query(`
+		select id, user_id, name, scopes, expires_at, last_used_at, created_at from personal_access_tokens
+		where user_id = ? and revoked_at is null
+		order by created_at desc
+		`,
+		userID,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	var tokens []models.PersonalAccessToken
+	for lines.Next() {
+		var token models.PersonalAccessToken
+		var scopes string
+		if error := lines.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&scopes,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+		token.Scopes = strings.Fields(scopes)
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// Touch records the token usage at most once a minute to avoid a write per request.
+func (repositoryTokens personalAccessTokens) Touch(ID uint64) error {
+	statement, error := repositoryTokens.db.Prepare(`
+		update personal_access_tokens set last_used_at = current_timestamp()
+		where id = ? and (last_used_at is null or last_used_at < now() - interval 1 minute)
+	`)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryTokens personalAccessTokens) Revoke(ID, userID uint64) (bool, error) {
+	statement, error := repositoryTokens.db.Prepare(
+		"update personal_access_tokens set revoked_at = current_timestamp() where id = ? and user_id = ? and revoked_at is null",
+	)
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(ID, userID)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// RevokeAll revokes every token of the user, as when their password changes.
+func (repositoryTokens personalAccessTokens) RevokeAll(userID uint64) error {
+	statement, error := repositoryTokens.db.Prepare(
+		"update personal_access_tokens set revoked_at = current_timestamp() where user_id = ? and revoked_at is null",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(userID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 	"social-network/src/models"
+	"strings"
 )
 
 type posts struct {
@@ -13,102 +14,400 @@ func NewRepositoryPosts(db *sql.DB) *posts {
 	return &posts{db}
 }
 
+// postColumns are read by scanPost, in the same order, by every query that
+// lists posts joined with their authors as u.
+const postColumns = `
+	p.id, p.title, p.content, p.author_id, u.nick, p.in_reply_to_id, p.quoted_post_id, p.likes,
+	(select count(*) from comments c where c.post_id = p.id),
+	(select count(*) from posts r where r.in_reply_to_id = p.id),
+	(select count(*) from reposts rp where rp.post_id = p.id),
+	p.created_at
+`
+
+// scanPost reads postColumns followed by the extra columns of the query.
+func scanPost(lines *sql.Rows, extra ...interface{}) (models.Post, error) {
+	var post models.Post
+	columns := append([]interface{}{
+		&post.ID,
+		&post.Title,
+		&post.Content,
+		&post.AuthorID,
+		&post.AuthorNick,
+		&post.InReplyToID,
+		&post.QuotedPostID,
+		&post.Likes,
+		&post.CommentsCount,
+		&post.RepliesCount,
+		&post.RepostsCount,
+		&post.CreatedAt,
+	}, extra...)
+	if error := lines.Scan(columns...); error != nil {
+		return models.Post{}, error
+	}
+	return post, nil
+}
+
+// queryPosts runs a query selecting postColumns and completes the posts with
+// what is loaded apart.
+func (repositoryPosts posts) queryPosts(viewerID uint64, query string, args ...interface{}) ([]models.Post, error) {
+	lines, error := repositoryPosts.db.Query(query, args...)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	posts := []models.Post{}
+	for lines.Next() {
+		post, error := scanPost(lines)
+		if error != nil {
+			return nil, error
+		}
+		posts = append(posts, post)
+	}
+	if error := lines.Err(); error != nil {
+		return nil, error
+	}
+
+	if error := repositoryPosts.completePosts(posts, viewerID); error != nil {
+		return nil, error
+	}
+	return posts, nil
+}
+
+// completePosts loads for a page of posts what would cost a subquery per
+// post: their details and the quoted posts.
+func (repositoryPosts posts) completePosts(posts []models.Post, viewerID uint64) error {
+	if error := repositoryPosts.loadDetails(posts, viewerID); error != nil {
+		return error
+	}
+	return repositoryPosts.loadQuotedPosts(posts, viewerID)
+}
+
+// loadDetails loads the tags, the mentions, the reactions and what the viewer
+// did with each post.
+func (repositoryPosts posts) loadDetails(posts []models.Post, viewerID uint64) error {
+	if error := loadTags(repositoryPosts.db, posts); error != nil {
+		return error
+	}
+	if error := loadMentions(repositoryPosts.db, posts); error != nil {
+		return error
+	}
+	if error := loadReactions(repositoryPosts.db, posts, viewerID); error != nil {
+		return error
+	}
+	return repositoryPosts.loadViewerState(posts, viewerID)
+}
+
+// loadViewerState marks the posts the viewer liked, reposted or bookmarked.
+func (repositoryPosts posts) loadViewerState(posts []models.Post, viewerID uint64) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	indexes := map[uint64][]int{}
+	args := []interface{}{viewerID, viewerID, viewerID}
+	for index, post := range posts {
+		if _, ok := indexes[post.ID]; !ok {
+			args = append(args, post.ID)
+		}
+		indexes[post.ID] = append(indexes[post.ID], index)
+	}
+
+	lines, error := repositoryPosts.db.Query(`
+		select p.id,
+			exists(select 1 from post_likes l where l.post_id = p.id and l.user_id = ?),
+			exists(select 1 from reposts rp where rp.post_id = p.id and rp.user_id = ?),
+			exists(select 1 from bookmarks b where b.post_id = p.id and b.user_id = ?)
+		from posts p
+		where p.id in (`+placeholders(len(args)-3)+`)
+		`,
+		args...,
+	)
+	if error != nil {
+		return error
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var (
+			postID         uint64
+			likedByMe      bool
+			repostedByMe   bool
+			bookmarkedByMe bool
+		)
+		if error := lines.Scan(&postID, &likedByMe, &repostedByMe, &bookmarkedByMe); error != nil {
+			return error
+		}
+
+		for _, index := range indexes[postID] {
+			posts[index].LikedByMe = likedByMe
+			posts[index].RepostedByMe = repostedByMe
+			posts[index].BookmarkedByMe = bookmarkedByMe
+		}
+	}
+
+	return lines.Err()
+}
+
+// loadQuotedPosts embeds in quote posts the post they quote. A quoted post
+// that was deleted, or whose author blocked the author of the quote, is left
+// out, keeping only its ID.
+func (repositoryPosts posts) loadQuotedPosts(posts []models.Post, viewerID uint64) error {
+	indexes := map[uint64][]int{}
+	args := []interface{}{}
+	for index, post := range posts {
+		if post.QuotedPostID == nil {
+			continue
+		}
+		if _, ok := indexes[post.ID]; !ok {
+			args = append(args, post.ID)
+		}
+		indexes[post.ID] = append(indexes[post.ID], index)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	lines, error := repositoryPosts.db.Query(`
+		select `+postColumns+`, q.id from posts q
+			inner join posts p on p.id = q.quoted_post_id
+			inner join users u on u.id = p.author_id
+		where q.id in (`+placeholders(len(args))+`)
+			and not exists (
+				select 1 from user_blocks b where b.blocker_id = p.author_id and b.blocked_id = q.author_id
+			)
+		`,
+		args...,
+	)
+	if error != nil {
+		return error
+	}
+	defer lines.Close()
+
+	quotedPosts := []models.Post{}
+	quotingIDs := []uint64{}
+	for lines.Next() {
+		var quotingID uint64
+		quotedPost, error := scanPost(lines, &quotingID)
+		if error != nil {
+			return error
+		}
+		quotedPosts = append(quotedPosts, quotedPost)
+		quotingIDs = append(quotingIDs, quotingID)
+	}
+	if error := lines.Err(); error != nil {
+		return error
+	}
+
+	// Quoted posts are shown one level deep, without the posts they quote.
+	if error := repositoryPosts.loadDetails(quotedPosts, viewerID); error != nil {
+		return error
+	}
+
+	for position, quotedPost := range quotedPosts {
+		for _, index := range indexes[quotingIDs[position]] {
+			quotedPost := quotedPost
+			posts[index].QuotedPost = &quotedPost
+		}
+	}
+	return nil
+}
+
+// placeholders returns the list of placeholders of an in clause with size
+// values.
+func placeholders(size int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", size), ", ")
+}
+
+// Create stores the post along with its tags and mentions.
 func (repositoryPosts posts) Create(post models.Post) (uint64, error) {
-	statement, error := repositoryPosts.db.Prepare("insert into posts (title, content, author_id) values (?, ?, ?)")
+	transaction, error := repositoryPosts.db.Begin()
 	if error != nil {
 		return 0, error
 	}
-	defer statement.Close()
+	defer transaction.Rollback()
 
-	result, error := statement.Exec(post.Title, post.Content, post.AuthorID)
+	result, error := transaction.Exec(
+		"insert into posts (title, content, author_id, in_reply_to_id, quoted_post_id) values (?, ?, ?, ?, ?)",
+		post.Title,
+		post.Content,
+		post.AuthorID,
+		post.InReplyToID,
+		post.QuotedPostID,
+	)
 	if error != nil {
 		return 0, error
 	}
 	lastId, error := result.LastInsertId()
 	if error != nil {
-		return 0, nil
+		return 0, error
+	}
+
+	if error := setPostTags(transaction, uint64(lastId), post.Tags); error != nil {
+		return 0, error
+	}
+	if error := setPostMentions(transaction, uint64(lastId), post.AuthorID, post.Mentions); error != nil {
+		return 0, error
 	}
 
+	if error := transaction.Commit(); error != nil {
+		return 0, error
+	}
 	return uint64(lastId), nil
 }
 
-func (repositoryPosts posts) GetPost(postId uint64) (models.Post, error) {
-	line, error := repositoryPosts.db.Query(`
-		select p.*, u.id from posts p
+func (repositoryPosts posts) GetPost(postId, viewerID uint64) (models.Post, error) {
+	posts, error := repositoryPosts.queryPosts(viewerID, `
+		select `+postColumns+` from posts p
 			inner join users u on u.id = p.author_id
 		where p.id = ?
 		`,
 		postId,
 	)
-	if error != nil {
+	if error != nil || len(posts) == 0 {
 		return models.Post{}, error
 	}
-
-	var post models.Post
-	if line.Next() {
-		if error := line.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.AuthorID,
-			&post.Likes,
-			&post.CreatedAt,
-			&post.AuthorNick,
-		); error != nil {
-			return models.Post{}, error
-		}
-	}
-	return post, nil
+	return posts[0], nil
 }
 
-func (repositoryPosts posts) ListPosts(userID uint64) ([]models.Post, error) {
+// ListPosts returns the timeline of the user: their posts and the ones of
+// who they follow, optionally leaving replies out, along with what they
+// reposted. Each post shows up once, as an original when its author is in
+// the timeline, otherwise attributed to the latest repost.
+func (repositoryPosts posts) ListPosts(userID uint64, includeReplies bool) ([]models.Post, error) {
 	lines, error := repositoryPosts.db.Query(`
-			select distinct p.*, u.nick from posts p
-				inner join users u on u.id = p.author_id
-				inner join followers f on f.user_id = p.author_id
-			where u.id = ? or f.follower_id = ?
-			order by 1 desc
+		with timeline (post_id, reposter_id, activity_at) as (
+			select p.id, null, p.created_at from posts p
+			where (p.author_id = ? or p.author_id in (select user_id from followers where follower_id = ?))
+				and (? or p.in_reply_to_id is null)
+			union all
+			select rp.post_id, rp.user_id, rp.created_at from reposts rp
+			where rp.user_id = ? or rp.user_id in (select user_id from followers where follower_id = ?)
+		), ranked as (
+			select post_id, reposter_id, activity_at,
+				row_number() over (
+					partition by post_id order by reposter_id is null desc, activity_at desc
+				) as position
+			from timeline
+		)
+		select `+postColumns+`, ru.id, ru.nick from ranked t
+			inner join posts p on p.id = t.post_id
+			inner join users u on u.id = p.author_id
+			left join users ru on ru.id = t.reposter_id
+		where t.position = 1
+		order by t.activity_at desc, p.id desc
 		`,
 		userID,
 		userID,
+		includeReplies,
+		userID,
+		userID,
 	)
 	if error != nil {
 		return nil, error
 	}
 	defer lines.Close()
 
-	var posts []models.Post
+	posts := []models.Post{}
 	for lines.Next() {
-		var post models.Post
-		if error := lines.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.AuthorID,
-			&post.Likes,
-			&post.CreatedAt,
-			&post.AuthorNick,
-		); error != nil {
+		var (
+			reposterID   sql.NullInt64
+			reposterNick sql.NullString
+		)
+		post, error := scanPost(lines, &reposterID, &reposterNick)
+		if error != nil {
 			return nil, error
 		}
+		if reposterID.Valid {
+			post.RepostedBy = &models.User{ID: uint64(reposterID.Int64), Nick: reposterNick.String}
+		}
 		posts = append(posts, post)
 	}
+	if error := lines.Err(); error != nil {
+		return nil, error
+	}
 
+	if error := repositoryPosts.completePosts(posts, userID); error != nil {
+		return nil, error
+	}
 	return posts, nil
 }
 
+// GetAncestors returns the posts the given one replies to, directly or not,
+// starting at the root of the thread.
+func (repositoryPosts posts) GetAncestors(postID, viewerID uint64) ([]models.Post, error) {
+	return repositoryPosts.queryPosts(viewerID, `
+		with recursive ancestors (id, in_reply_to_id, depth) as (
+			select id, in_reply_to_id, 0 from posts where id = ?
+			union all
+			select parent.id, parent.in_reply_to_id, a.depth + 1 from posts parent
+				inner join ancestors a on a.in_reply_to_id = parent.id
+		)
+		select `+postColumns+` from ancestors a
+			inner join posts p on p.id = a.id
+			inner join users u on u.id = p.author_id
+		where a.depth > 0
+		order by a.depth desc
+		`,
+		postID,
+	)
+}
+
+// GetReplies returns, for each of the given posts, its direct replies from
+// offset on, at most limit of them per post and maxReplies in total.
+func (repositoryPosts posts) GetReplies(postIDs []uint64, limit, offset, maxReplies, viewerID uint64) ([]models.Post, error) {
+	if len(postIDs) == 0 {
+		return []models.Post{}, nil
+	}
+
+	args := make([]interface{}, 0, len(postIDs)+3)
+	for _, postID := range postIDs {
+		args = append(args, postID)
+	}
+	args = append(args, offset, offset+limit, maxReplies)
+
+	return repositoryPosts.queryPosts(viewerID, `
+		select `+postColumns+` from posts p
+			inner join users u on u.id = p.author_id
+			inner join (
+				select id, row_number() over (partition by in_reply_to_id order by id) as position
+				from posts where in_reply_to_id in (`+placeholders(len(postIDs))+`)
+			) r on r.id = p.id
+		where r.position > ? and r.position <= ?
+		order by p.id
+		limit ?
+		`,
+		args...,
+	)
+}
+
+// UpdatePost changes the post and replaces its tags and mentions with the
+// ones now in it.
 func (repositoryPosts posts) UpdatePost(postId uint64, post models.Post) error {
-	statement, error := repositoryPosts.db.Prepare("update posts set title = ?, content = ? where author_id = ? and id = ?")
+	transaction, error := repositoryPosts.db.Begin()
 	if error != nil {
 		return error
 	}
-	defer statement.Close()
+	defer transaction.Rollback()
 
-	if _, error := statement.Exec(post.Title, post.Content, post.AuthorID, postId); error != nil {
+	if _, error := transaction.Exec(
+		"update posts set title = ?, content = ? where author_id = ? and id = ?",
+		post.Title,
+		post.Content,
+		post.AuthorID,
+		postId,
+	); error != nil {
 		return error
 	}
 
-	return nil
+	if error := setPostTags(transaction, postId, post.Tags); error != nil {
+		return error
+	}
+	if error := setPostMentions(transaction, postId, post.AuthorID, post.Mentions); error != nil {
+		return error
+	}
+
+	return transaction.Commit()
 }
 
 func (repositoryPosts posts) DeletePost(postId uint64) error {
@@ -125,68 +424,196 @@ func (repositoryPosts posts) DeletePost(postId uint64) error {
 	return nil
 }
 
-func (repositoryPosts posts) GetPostsPerUser(userId uint64) ([]models.Post, error) {
-	lines, error := repositoryPosts.db.Query(`
-		select p.*, u.nick from posts p
+func (repositoryPosts posts) GetPostsPerUser(userId, viewerID uint64) ([]models.Post, error) {
+	return repositoryPosts.queryPosts(viewerID, `
+		select `+postColumns+` from posts p
 			inner join users u on u.id = p.author_id
 		where p.author_id = ?
+		order by p.id desc
 		`,
 		userId,
 	)
+}
+
+// LikePost records the like of the user, counting it on the post only when
+// the user hadn't liked it yet, so liking again changes nothing.
+func (repositoryPosts posts) LikePost(postID, userID uint64) error {
+	transaction, error := repositoryPosts.db.Begin()
 	if error != nil {
-		return nil, error
+		return error
 	}
+	defer transaction.Rollback()
 
-	var posts []models.Post
-	for lines.Next() {
-		var post models.Post
-		if error := lines.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.AuthorID,
-			&post.Likes,
-			&post.CreatedAt,
-			&post.AuthorNick,
-		); error != nil {
-			return nil, error
+	result, error := transaction.Exec("insert ignore into post_likes (user_id, post_id) values (?, ?)", userID, postID)
+	if error != nil {
+		return error
+	}
+
+	if rowsAffected, error := result.RowsAffected(); error != nil {
+		return error
+	} else if rowsAffected == 1 {
+		if _, error := transaction.Exec("update posts set likes = likes + 1 where id = ?", postID); error != nil {
+			return error
 		}
-		posts = append(posts, post)
 	}
-	return posts, nil
+
+	return transaction.Commit()
 }
 
-func (repositoryPosts posts) LikePost(postId uint64) error {
-	statement, error := repositoryPosts.db.Prepare("update posts set likes = likes + 1 where id = ?")
+// UnlikePost removes the like of the user, if there was one.
+func (repositoryPosts posts) UnlikePost(postID, userID uint64) error {
+	transaction, error := repositoryPosts.db.Begin()
+	if error != nil {
+		return error
+	}
+	defer transaction.Rollback()
+
+	result, error := transaction.Exec("delete from post_likes where user_id = ? and post_id = ?", userID, postID)
+	if error != nil {
+		return error
+	}
+
+	if rowsAffected, error := result.RowsAffected(); error != nil {
+		return error
+	} else if rowsAffected == 1 {
+		if _, error := transaction.Exec("update posts set likes = likes - 1 where id = ? and likes > 0", postID); error != nil {
+			return error
+		}
+	}
+
+	return transaction.Commit()
+}
+
+// Repost is idempotent, a user reposts a post at most once.
+func (repositoryPosts posts) Repost(postID, userID uint64) error {
+	statement, error := repositoryPosts.db.Prepare("insert ignore into reposts (user_id, post_id) values (?, ?)")
 	if error != nil {
 		return error
 	}
 	defer statement.Close()
 
-	if _, error := statement.Exec(postId); error != nil {
+	if _, error := statement.Exec(userID, postID); error != nil {
 		return error
 	}
 
 	return nil
 }
 
-func (repositoryPosts posts) UnlikePost(postId uint64) error {
-	statement, error := repositoryPosts.db.Prepare(`
-		update posts set likes = 
-		case
-			when likes > 0 then likes - 1 
-			else 0
-		end
-		where id = ?
-	`)
+func (repositoryPosts posts) Unrepost(postID, userID uint64) error {
+	statement, error := repositoryPosts.db.Prepare("delete from reposts where user_id = ? and post_id = ?")
 	if error != nil {
 		return error
 	}
 	defer statement.Close()
 
-	if _, error := statement.Exec(postId); error != nil {
+	if _, error := statement.Exec(userID, postID); error != nil {
 		return error
 	}
 
 	return nil
 }
+
+// GetLikers returns the users who liked the post, the latest first.
+func (repositoryPosts posts) GetLikers(postID, limit, offset uint64) ([]models.User, error) {
+	lines, error := repositoryPosts.db.Query(`
+		select u.id, u.name, u.nick, u.created_at from post_likes l
+			inner join users u on u.id = l.user_id
+		where l.post_id = ?
+		order by l.created_at desc, u.id desc
+		limit ? offset ?
+		`,
+		postID,
+		limit,
+		offset,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	users := []models.User{}
+	for lines.Next() {
+		var user models.User
+		if error := lines.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Nick,
+			&user.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// GetLikedPosts returns the posts the user liked, the latest likes first.
+func (repositoryPosts posts) GetLikedPosts(userID, limit, offset, viewerID uint64) ([]models.Post, error) {
+	return repositoryPosts.queryPosts(viewerID, `
+		select `+postColumns+` from post_likes l
+			inner join posts p on p.id = l.post_id
+			inner join users u on u.id = p.author_id
+		where l.user_id = ?
+		order by l.created_at desc, p.id desc
+		limit ? offset ?
+		`,
+		userID,
+		limit,
+		offset,
+	)
+}
+
+// GetBookmarkedPosts returns the posts the user bookmarked, the latest first,
+// optionally only the ones in a collection.
+func (repositoryPosts posts) GetBookmarkedPosts(userID uint64, collectionID *uint64, limit, offset uint64) ([]models.Post, error) {
+	return repositoryPosts.queryPosts(userID, `
+		select `+postColumns+` from bookmarks b
+			inner join posts p on p.id = b.post_id
+			inner join users u on u.id = p.author_id
+		where b.user_id = ? and (? is null or b.collection_id = ?)
+		order by b.created_at desc, p.id desc
+		limit ? offset ?
+		`,
+		userID,
+		collectionID,
+		collectionID,
+		limit,
+		offset,
+	)
+}
+
+// GetPostsForTag returns the posts with the tag, the latest first.
+func (repositoryPosts posts) GetPostsForTag(tag string, limit, offset, viewerID uint64) ([]models.Post, error) {
+	return repositoryPosts.queryPosts(viewerID, `
+		select `+postColumns+` from post_tags pt
+			inner join tags t on t.id = pt.tag_id
+			inner join posts p on p.id = pt.post_id
+			inner join users u on u.id = p.author_id
+		where t.name = ?
+		order by p.id desc
+		limit ? offset ?
+		`,
+		tag,
+		limit,
+		offset,
+	)
+}
+
+// GetMentioningPosts returns the posts mentioning the user, the latest first,
+// leaving out the ones of authors the user blocked.
+func (repositoryPosts posts) GetMentioningPosts(userID, limit, offset uint64) ([]models.Post, error) {
+	return repositoryPosts.queryPosts(userID, `
+		select `+postColumns+` from posts p
+			inner join users u on u.id = p.author_id
+		where exists (select 1 from post_mentions m where m.post_id = p.id and m.user_id = ?)
+			and not exists (select 1 from user_blocks b where b.blocker_id = ? and b.blocked_id = p.author_id)
+		order by p.id desc
+		limit ? offset ?
+		`,
+		userID,
+		userID,
+		limit,
+		offset,
+	)
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type reactions struct {
+	db *sql.DB
+}
+
+func NewRepositoryReactions(db *sql.DB) *reactions {
+	return &reactions{db}
+}
+
+// Add is idempotent, the user reacts with each kind at most once per post.
+func (repositoryReactions reactions) Add(postID, userID uint64, kind string) error {
+	statement, error := repositoryReactions.db.Prepare(
+		"insert ignore into post_reactions (post_id, user_id, kind) values (?, ?, ?)",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(postID, userID, kind); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryReactions reactions) Remove(postID, userID uint64, kind string) error {
+	statement, error := repositoryReactions.db.Prepare(
+		"delete from post_reactions where post_id = ? and user_id = ? and kind = ?",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(postID, userID, kind); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// ListReactors returns who reacted to the post, the latest first, optionally
+// only with the given kind.
+func (repositoryReactions reactions) ListReactors(postID uint64, kind string, limit, offset uint64) ([]models.Reaction, error) {
+	lines, error := repositoryReactions.db.Query(`
+		select r.kind, u.id, u.name, u.nick, r.created_at from post_reactions r
+			inner join users u on u.id = r.user_id
+		where r.post_id = ? and (? = '' or r.kind = ?)
+		order by r.created_at desc, u.id desc
+		limit ? offset ?
+		`,
+		postID,
+		kind,
+		kind,
+		limit,
+		offset,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	reactions := []models.Reaction{}
+	for lines.Next() {
+		var reaction models.Reaction
+		if error := lines.Scan(
+			&reaction.Kind,
+			&reaction.User.ID,
+			&reaction.User.Name,
+			&reaction.User.Nick,
+			&reaction.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, nil
+}
+
+// loadReactions fills the reaction counts of the posts, per kind, and the
+// kinds the viewer reacted with.
+func loadReactions(db *sql.DB, posts []models.Post, viewerID uint64) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	indexes := map[uint64][]int{}
+	args := make([]interface{}, 0, len(posts)+1)
+	args = append(args, viewerID)
+	for index := range posts {
+		posts[index].Reactions = map[string]uint64{}
+		posts[index].MyReactions = []string{}
+		if _, ok := indexes[posts[index].ID]; !ok {
+			args = append(args, posts[index].ID)
+		}
+		indexes[posts[index].ID] = append(indexes[posts[index].ID], index)
+	}
+
+	lines, error := db.Query(`
+		select post_id, kind, count(*), sum(user_id = ?) from post_reactions
+		where post_id in (`+placeholders(len(args)-1)+`)
+		group by post_id, kind
+		`,
+		args...,
+	)
+	if error != nil {
+		return error
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var (
+			postID uint64
+			kind   string
+			total  uint64
+			mine   uint64
+		)
+		if error := lines.Scan(&postID, &kind, &total, &mine); error != nil {
+			return error
+		}
+
+		for _, index := range indexes[postID] {
+			posts[index].Reactions[kind] = total
+			if mine > 0 {
+				posts[index].MyReactions = append(posts[index].MyReactions, kind)
+			}
+		}
+	}
+
+	return lines.Err()
+}
@@ -0,0 +1,240 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+	"strings"
+)
+
+type sessions struct {
+	db *sql.DB
+}
+
+func NewRepositorySessions(db *sql.DB) *sessions {
+	return &sessions{db}
+}
+
+func (repositorySessions sessions) Create(session models.Session) (uint64, error) {
+	statement, error := repositorySessions.db.Prepare(
+		`insert into sessions (user_id, refresh_token_hash, user_agent, ip_address, scopes, client_id, expires_at)
+			values (?, ?, ?, ?, ?, nullif(?, ''), ?)`,
+	)
+	if error != nil {
+		return 0, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(
+		session.UserID,
+		session.RefreshTokenHash,
+		session.UserAgent,
+		session.IPAddress,
+		strings.Join(session.Scopes, " "),
+		session.ClientID,
+		session.ExpiresAt,
+	)
+	if error != nil {
+		return 0, error
+	}
+
+	lastIDInserted, error := result.LastInsertId()
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastIDInserted), nil
+}
+
+func (repositorySessions sessions) GetSession(ID uint64) (models.Session, error) {
+	line, error := repositorySessions.db.Query(
+		`select id, user_id, refresh_token_hash, user_agent, ip_address, scopes, coalesce(client_id, ''),
+				expires_at, revoked_at, created_at
+			from sessions where id = ?`,
+		ID,
+	)
+	if error != nil {
+		return models.Session{}, error
+	}
+	defer line.Close()
+
+	var session models.Session
+	var scopes string
+	if line.Next() {
+		if error := line.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.RefreshTokenHash,
+			&session.UserAgent,
+			&session.IPAddress,
+			&scopes,
+			&session.ClientID,
+			&session.ExpiresAt,
+			&session.RevokedAt,
+			&session.CreatedAt,
+		); error != nil {
+			return models.Session{}, error
+		}
+	}
+	session.Scopes = strings.Fields(scopes)
+	return session, nil
+}
+
+// RotateRefreshToken replaces the refresh token hash of the session, keeping
+// the replaced one so its reuse can be told apart from a guessed token.
+func (repositorySessions sessions) RotateRefreshToken(ID uint64, actualHash, newHash string) (bool, error) {
+	transaction, error := repositorySessions.db.Begin()
+	if error != nil {
+		return false, error
+	}
+	defer transaction.Rollback()
+
+	result, error := transaction.Exec(
+		"update sessions set refresh_token_hash = ? where id = ? and refresh_token_hash = ? and revoked_at is null",
+		newHash,
+		ID,
+		actualHash,
+	)
+	if error != nil {
+		return false, error
+	}
+	if rowsAffected, error := result.RowsAffected(); error != nil {
+		return false, error
+	} else if rowsAffected != 1 {
+		return false, nil
+	}
+
+	if _, error := transaction.Exec(
+		"insert into rotated_refresh_tokens (refresh_token_hash, session_id) values (?, ?)",
+		actualHash,
+		ID,
+	); error != nil {
+		return false, error
+	}
+
+	return true, transaction.Commit()
+}
+
+// WasRotated tells whether the hash belonged to a refresh token of the
+// session that was already replaced.
+func (repositorySessions sessions) WasRotated(ID uint64, refreshTokenHash string) (bool, error) {
+	var rotated bool
+	if error := repositorySessions.db.QueryRow(
+		"select exists(select 1 from rotated_refresh_tokens where session_id = ? and refresh_token_hash = ?)",
+		ID,
+		refreshTokenHash,
+	).Scan(&rotated); error != nil {
+		return false, error
+	}
+	return rotated, nil
+}
+
+func (repositorySessions sessions) Revoke(ID uint64) error {
+	statement, error := repositorySessions.db.Prepare(
+		"update sessions set revoked_at = current_timestamp() where id = ? and revoked_at is null",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositorySessions sessions) IsActive(ID uint64) (bool, error) {
+	session, error := repositorySessions.GetSession(ID)
+	if error != nil {
+		return false, error
+	}
+	return session.ID != 0 && session.Active(), nil
+}
+
+func (repositorySessions sessions) ListActive(userID uint64) ([]models.Session, error) {
+	lines, error := repositorySessions.db.Query(`
+		select id, user_id, user_agent, ip_address, coalesce(client_id, ''), expires_at, created_at from sessions
+		where user_id = ? and revoked_at is null and expires_at > now()
+		order by created_at desc
+		`,
+		userID,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	var sessions []models.Session
+	for lines.Next() {
+		var session models.Session
+		if error := lines.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.ClientID,
+			&session.ExpiresAt,
+			&session.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (repositorySessions sessions) RevokeForUser(ID, userID uint64) (bool, error) {
+	statement, error := repositorySessions.db.Prepare(
+		"update sessions set revoked_at = current_timestamp() where id = ? and user_id = ? and revoked_at is null",
+	)
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(ID, userID)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (repositorySessions sessions) RevokeAllExcept(userID, sessionID uint64) error {
+	statement, error := repositorySessions.db.Prepare(
+		"update sessions set revoked_at = current_timestamp() where user_id = ? and id <> ? and revoked_at is null",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(userID, sessionID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositorySessions sessions) RevokeAll(userID uint64) error {
+	statement, error := repositorySessions.db.Prepare(
+		"update sessions set revoked_at = current_timestamp() where user_id = ? and revoked_at is null",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(userID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+	"strings"
+	"time"
+)
+
+type tags struct {
+	db *sql.DB
+}
+
+func NewRepositoryTags(db *sql.DB) *tags {
+	return &tags{db}
+}
+
+// ListTrending returns the trending tags last computed for the window.
+func (repositoryTags tags) ListTrending(window time.Duration, limit uint64) ([]models.TrendingTag, error) {
+	lines, error := repositoryTags.db.Query(`
+		select t.name, tt.score, tt.posts_count from trending_tags tt
+			inner join tags t on t.id = tt.tag_id
+		where tt.window_seconds = ?
+		order by tt.score desc, t.name
+		limit ?
+		`,
+		uint64(window.Seconds()),
+		limit,
+	)
+	if error != nil {
+		return nil, error
+	}
+	defer lines.Close()
+
+	trendingTags := []models.TrendingTag{}
+	for lines.Next() {
+		var trendingTag models.TrendingTag
+		if error := lines.Scan(&trendingTag.Name, &trendingTag.Score, &trendingTag.PostsCount); error != nil {
+			return nil, error
+		}
+		trendingTags = append(trendingTags, trendingTag)
+	}
+
+	return trendingTags, nil
+}
+
+// RecomputeTrending replaces the trending tags of the window with the ones
+// most used by the posts made within it. Each use weighs half as much every
+// halfLife, and an author only counts once per tag, with their latest post,
+// so nobody can push a tag alone.
+func (repositoryTags tags) RecomputeTrending(window, halfLife time.Duration, limit int) error {
+	transaction, error := repositoryTags.db.Begin()
+	if error != nil {
+		return error
+	}
+	defer transaction.Rollback()
+
+	windowSeconds := uint64(window.Seconds())
+	if _, error := transaction.Exec("delete from trending_tags where window_seconds = ?", windowSeconds); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec(`
+		insert into trending_tags (window_seconds, tag_id, score, posts_count)
+		select ?, tag_id, sum(weight), sum(posts_count) from (
+			select pt.tag_id,
+				max(pow(0.5, timestampdiff(second, p.created_at, now()) / ?)) as weight,
+				count(*) as posts_count
+			from post_tags pt
+				inner join posts p on p.id = pt.post_id
+			where p.created_at >= now() - interval ? second
+			group by pt.tag_id, p.author_id
+		) uses
+		group by tag_id
+		order by sum(weight) desc
+		limit ?
+		`,
+		windowSeconds,
+		halfLife.Seconds(),
+		windowSeconds,
+		limit,
+	); error != nil {
+		return error
+	}
+
+	return transaction.Commit()
+}
+
+// setPostTags replaces the tags of the post, creating the ones never used
+// before.
+func setPostTags(transaction *sql.Tx, postID uint64, postTags []string) error {
+	if _, error := transaction.Exec("delete from post_tags where post_id = ?", postID); error != nil {
+		return error
+	}
+	if len(postTags) == 0 {
+		return nil
+	}
+
+	names := make([]interface{}, 0, len(postTags))
+	for _, tag := range postTags {
+		names = append(names, tag)
+	}
+
+	if _, error := transaction.Exec(
+		"insert ignore into tags (name) values "+strings.TrimSuffix(strings.Repeat("(?), ", len(names)), ", "),
+		names...,
+	); error != nil {
+		return error
+	}
+
+	args := append([]interface{}{postID}, names...)
+	args = append(args, names...)
+	if _, error := transaction.Exec(`
+		insert into post_tags (post_id, tag_id, position)
+		select ?, id, field(name, `+placeholders(len(names))+`) from tags
+		where name in (`+placeholders(len(names))+`)
+		`,
+		args...,
+	); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// loadTags fills the tags of a page of posts, in the order they were
+// written.
+func loadTags(db *sql.DB, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	indexes := map[uint64][]int{}
+	args := make([]interface{}, 0, len(posts))
+	for index := range posts {
+		posts[index].Tags = []string{}
+		if _, ok := indexes[posts[index].ID]; !ok {
+			args = append(args, posts[index].ID)
+		}
+		indexes[posts[index].ID] = append(indexes[posts[index].ID], index)
+	}
+
+	lines, error := db.Query(`
+		select pt.post_id, t.name from post_tags pt
+			inner join tags t on t.id = pt.tag_id
+		where pt.post_id in (`+placeholders(len(args))+`)
+		order by pt.post_id, pt.position
+		`,
+		args...,
+	)
+	if error != nil {
+		return error
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var (
+			postID uint64
+			name   string
+		)
+		if error := lines.Scan(&postID, &name); error != nil {
+			return error
+		}
+
+		for _, index := range indexes[postID] {
+			posts[index].Tags = append(posts[index].Tags, name)
+		}
+	}
+
+	return lines.Err()
+}
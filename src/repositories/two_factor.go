@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type twoFactor struct {
+	db *sql.DB
+}
+
+func NewRepositoryTwoFactor(db *sql.DB) *twoFactor {
+	return &twoFactor{db}
+}
+
+func (repositoryTwoFactor twoFactor) Get(userID uint64) (models.TwoFactor, error) {
+	line, error := repositoryTwoFactor.db.Query(
+		"select id, coalesce(totp_secret, ''), totp_enabled_at, totp_last_step from users where id = ?",
+		userID,
+	)
+	if error != nil {
+		return models.TwoFactor{}, error
+	}
+	defer line.Close()
+
+	var twoFactor models.TwoFactor
+	if line.Next() {
+		if error := line.Scan(
+			&twoFactor.UserID,
+			&twoFactor.Secret,
+			&twoFactor.EnabledAt,
+			&twoFactor.LastStep,
+		); error != nil {
+			return models.TwoFactor{}, error
+		}
+	}
+	return twoFactor, nil
+}
+
+func (repositoryTwoFactor twoFactor) SetPendingSecret(userID uint64, secret string) error {
+	statement, error := repositoryTwoFactor.db.Prepare(
+		"update users set totp_secret = ?, totp_last_step = 0 where id = ? and totp_enabled_at is null",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(secret, userID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// Enable activates the pending secret and replaces any previous recovery codes.
+func (repositoryTwoFactor twoFactor) Enable(userID uint64, step int64, recoveryCodeHashes []string) error {
+	transaction, error := repositoryTwoFactor.db.Begin()
+	if error != nil {
+		return error
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec(
+		"update users set totp_enabled_at = current_timestamp(), totp_last_step = ? where id = ?",
+		step,
+		userID,
+	); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec("delete from recovery_codes where user_id = ?", userID); error != nil {
+		return error
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		if _, error := transaction.Exec(
+			"insert into recovery_codes (user_id, code_hash) values (?, ?)",
+			userID,
+			codeHash,
+		); error != nil {
+			return error
+		}
+	}
+
+	return transaction.Commit()
+}
+
+func (repositoryTwoFactor twoFactor) Disable(userID uint64) error {
+	transaction, error := repositoryTwoFactor.db.Begin()
+	if error != nil {
+		return error
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec(
+		"update users set totp_secret = null, totp_enabled_at = null, totp_last_step = 0 where id = ?",
+		userID,
+	); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec("delete from recovery_codes where user_id = ?", userID); error != nil {
+		return error
+	}
+
+	return transaction.Commit()
+}
+
+// UseStep records the accepted time step, failing when it was already used.
+func (repositoryTwoFactor twoFactor) UseStep(userID uint64, step int64) (bool, error) {
+	statement, error := repositoryTwoFactor.db.Prepare(
+		"update users set totp_last_step = ? where id = ? and totp_last_step < ?",
+	)
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(step, userID, step)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (repositoryTwoFactor twoFactor) UseRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	statement, error := repositoryTwoFactor.db.Prepare(
+		"update recovery_codes set used_at = current_timestamp() where user_id = ? and code_hash = ? and used_at is null",
+	)
+	if error != nil {
+		return false, error
+	}
+	defer statement.Close()
+
+	result, error := statement.Exec(userID, codeHash)
+	if error != nil {
+		return false, error
+	}
+
+	rowsAffected, error := result.RowsAffected()
+	if error != nil {
+		return false, error
+	}
+
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"database/sql"
+	"social-network/src/models"
+)
+
+type userIdentities struct {
+	db *sql.DB
+}
+
+func NewRepositoryUserIdentities(db *sql.DB) *userIdentities {
+	return &userIdentities{db}
+}
+
+func (repositoryIdentities userIdentities) Create(identity models.UserIdentity) error {
+	statement, error := repositoryIdentities.db.Prepare(
+		"insert into user_identities (user_id, provider, subject, email) values (?, ?, ?, ?)",
+	)
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(identity.UserID, identity.Provider, identity.Subject, identity.Email); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryIdentities userIdentities) GetUserID(provider, subject string) (uint64, error) {
+	line, error := repositoryIdentities.db.Query(
+		"select user_id from user_identities where provider = ? and subject = ?",
+		provider,
+		subject,
+	)
+	if error != nil {
+		return 0, error
+	}
+	defer line.Close()
+
+	var identity models.UserIdentity
+	if line.Next() {
+		if error := line.Scan(&identity.UserID); error != nil {
+			return 0, error
+		}
+	}
+	return identity.UserID, nil
+}
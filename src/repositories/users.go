@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"social-network/src/models"
+	"strings"
 )
 
 type users struct {
@@ -67,7 +68,7 @@ func (repositoryUser users) SearchUsers(filter string) ([]models.User, error) {
 
 func (repositoryUser users) GetUser(ID uint64) (models.User, error) {
 	line, error := repositoryUser.db.Query(
-		"select id, name, nick, email, created_at from users where ID = ?",
+		"select id, name, nick, email, role, created_at from users where ID = ?",
 		ID,
 	)
 	if error != nil {
@@ -82,6 +83,7 @@ func (repositoryUser users) GetUser(ID uint64) (models.User, error) {
 			&user.Name,
 			&user.Nick,
 			&user.Email,
+			&user.Role,
 			&user.CreatedAt,
 		); error != nil {
 			return models.User{}, error
@@ -106,18 +108,31 @@ func (repositoryUser users) UpdateUser(ID uint64, user models.User) error {
 	return nil
 }
 
+// DeleteUser also takes back the likes the user gave, since the cascade
+// removing them doesn't update the counters of the posts.
 func (repositoryUser users) DeleteUser(ID uint64) error {
-	statement, error := repositoryUser.db.Prepare("delete from users where id =?")
+	transaction, error := repositoryUser.db.Begin()
 	if error != nil {
 		return error
 	}
-	defer statement.Close()
+	defer transaction.Rollback()
 
-	if _, error := statement.Exec(ID); error != nil {
+	if _, error := transaction.Exec(`
+		update posts p
+			inner join post_likes l on l.post_id = p.id
+		set p.likes = p.likes - 1
+		where l.user_id = ? and p.likes > 0
+		`,
+		ID,
+	); error != nil {
 		return error
 	}
 
-	return nil
+	if _, error := transaction.Exec("delete from users where id = ?", ID); error != nil {
+		return error
+	}
+
+	return transaction.Commit()
 }
 
 func (repositoryUser users) GetUserForEmail(email string) (models.User, error) {
@@ -136,6 +151,32 @@ func (repositoryUser users) GetUserForEmail(email string) (models.User, error) {
 	return user, nil
 }
 
+func (repositoryUser users) GetUserForNick(nick string) (models.User, error) {
+	line, error := repositoryUser.db.Query("select id, password from users where nick = ?", nick)
+	if error != nil {
+		return models.User{}, error
+	}
+	defer line.Close()
+
+	var user models.User
+	if line.Next() {
+		if error := line.Scan(&user.ID, &user.Password); error != nil {
+			return models.User{}, error
+		}
+	}
+	return user, nil
+}
+
+// GetUserForIdentifier resolves a login identifier, which is an e-mail when
+// it has an @ and a nick otherwise. Both are compared ignoring case by the
+// collation of their columns.
+func (repositoryUser users) GetUserForIdentifier(identifier string) (models.User, error) {
+	if strings.Contains(identifier, "@") {
+		return repositoryUser.GetUserForEmail(identifier)
+	}
+	return repositoryUser.GetUserForNick(identifier)
+}
+
 func (repositoryUser users) FollowUser(userId, followerId uint64) error {
 	statement, error := repositoryUser.db.Prepare("insert ignore into followers(user_id, follower_id) values(?, ?)")
 	if error != nil {
@@ -164,6 +205,72 @@ func (repositoryUser users) UnfollowUser(userId, followerId uint64) error {
 	return nil
 }
 
+// BlockUser also undoes what ties the users: their follows and the reposts
+// of the blocked user of posts by the blocker.
+func (repositoryUser users) BlockUser(blockerID, blockedID uint64) error {
+	transaction, error := repositoryUser.db.Begin()
+	if error != nil {
+		return error
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec(
+		"insert ignore into user_blocks (blocker_id, blocked_id) values (?, ?)",
+		blockerID,
+		blockedID,
+	); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec(
+		"delete from followers where (user_id = ? and follower_id = ?) or (user_id = ? and follower_id = ?)",
+		blockerID,
+		blockedID,
+		blockedID,
+		blockerID,
+	); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec(
+		"delete from reposts where user_id = ? and post_id in (select id from posts where author_id = ?)",
+		blockedID,
+		blockerID,
+	); error != nil {
+		return error
+	}
+
+	return transaction.Commit()
+}
+
+func (repositoryUser users) UnblockUser(blockerID, blockedID uint64) error {
+	statement, error := repositoryUser.db.Prepare("delete from user_blocks where blocker_id = ? and blocked_id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(blockerID, blockedID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryUser users) IsBlocked(blockerID, blockedID uint64) (bool, error) {
+	line, error := repositoryUser.db.Query(
+		"select 1 from user_blocks where blocker_id = ? and blocked_id = ?",
+		blockerID,
+		blockedID,
+	)
+	if error != nil {
+		return false, error
+	}
+	defer line.Close()
+
+	return line.Next(), nil
+}
+
 func (repositoryUser users) GetFollowers(userId uint64) ([]models.User, error) {
 	lines, error := repositoryUser.db.Query(`
 		select u.id, u.name, u.nick, u.email from followers f
@@ -248,3 +355,115 @@ func (repositoryUser users) UpdatePassword(userId uint64, newPassword string) er
 
 	return nil
 }
+
+func (repositoryUser users) GetEmailStatus(ID uint64) (models.User, error) {
+	line, error := repositoryUser.db.Query(
+		"select id, email, coalesce(pending_email, ''), email_verified_at from users where id = ?",
+		ID,
+	)
+	if error != nil {
+		return models.User{}, error
+	}
+	defer line.Close()
+
+	var user models.User
+	if line.Next() {
+		if error := line.Scan(
+			&user.ID,
+			&user.Email,
+			&user.PendingEmail,
+			&user.EmailVerifiedAt,
+		); error != nil {
+			return models.User{}, error
+		}
+	}
+	return user, nil
+}
+
+// SetPendingEmail records the address the user is changing to, invalidating
+// the verifications sent for any other address they were changing to before.
+func (repositoryUser users) SetPendingEmail(ID uint64, email string) error {
+	transaction, error := repositoryUser.db.Begin()
+	if error != nil {
+		return error
+	}
+	defer transaction.Rollback()
+
+	if _, error := transaction.Exec("update users set pending_email = ? where id = ?", email, ID); error != nil {
+		return error
+	}
+
+	if _, error := transaction.Exec(`
+		update email_verifications set used_at = current_timestamp()
+		where user_id = ? and used_at is null
+			and email <> (select email from users where id = ?)
+		`,
+		ID,
+		ID,
+	); error != nil {
+		return error
+	}
+
+	return transaction.Commit()
+}
+
+// IsEmailTaken tells whether another user already has the address.
+func (repositoryUser users) IsEmailTaken(email string, exceptID uint64) (bool, error) {
+	line, error := repositoryUser.db.Query("select exists(select 1 from users where email = ? and id <> ?)", email, exceptID)
+	if error != nil {
+		return false, error
+	}
+	defer line.Close()
+
+	var taken bool
+	if line.Next() {
+		if error := line.Scan(&taken); error != nil {
+			return false, error
+		}
+	}
+	return taken, nil
+}
+
+func (repositoryUser users) GetRole(ID uint64) (string, error) {
+	line, error := repositoryUser.db.Query("select role from users where id = ?", ID)
+	if error != nil {
+		return "", error
+	}
+	defer line.Close()
+
+	var user models.User
+	if line.Next() {
+		if error := line.Scan(&user.Role); error != nil {
+			return "", error
+		}
+	}
+	return user.Role, nil
+}
+
+func (repositoryUser users) UpdateRole(ID uint64, role string) error {
+	statement, error := repositoryUser.db.Prepare("update users set role = ? where id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(role, ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func (repositoryUser users) MarkEmailVerified(ID uint64) error {
+	statement, error := repositoryUser.db.Prepare("update users set email_verified_at = current_timestamp() where id = ?")
+	if error != nil {
+		return error
+	}
+	defer statement.Close()
+
+	if _, error := statement.Exec(ID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesAuthentication = []Route{
+	{
+		URI:                    "/auth/refresh",
+		Method:                 http.MethodPost,
+		Function:               controllers.RefreshToken,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/logout",
+		Method:                 http.MethodPost,
+		Function:               controllers.Logout,
+		RequiresAuthentication: true,
+	},
+	{
+		URI:                    "/auth/forgot-password",
+		Method:                 http.MethodPost,
+		Function:               controllers.ForgotPassword,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/reset-password",
+		Method:                 http.MethodPost,
+		Function:               controllers.ResetPassword,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/verify-email",
+		Method:                 http.MethodPost,
+		Function:               controllers.VerifyEmail,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/verify-email/resend",
+		Method:                 http.MethodPost,
+		Function:               controllers.ResendEmailVerification,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/auth/magic-link",
+		Method:                 http.MethodPost,
+		Function:               controllers.RequestMagicLink,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/magic-link/callback",
+		Method:                 http.MethodGet,
+		Function:               controllers.MagicLinkCallback,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/.well-known/jwks.json",
+		Method:                 http.MethodGet,
+		Function:               controllers.GetJWKS,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/oidc/{provider}/login",
+		Method:                 http.MethodGet,
+		Function:               controllers.OIDCLogin,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/oidc/{provider}/callback",
+		Method:                 http.MethodGet,
+		Function:               controllers.OIDCCallback,
+		RequiresAuthentication: false,
+	},
+}
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesBookmarks = []Route{
+	{
+		URI:                    "/posts/{id}/bookmark",
+		Method:                 http.MethodPost,
+		Function:               controllers.BookmarkPost,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/posts/{id}/bookmark",
+		Method:                 http.MethodDelete,
+		Function:               controllers.UnbookmarkPost,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/users/me/bookmarks",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListBookmarks,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/users/me/bookmarks/collections",
+		Method:                 http.MethodPost,
+		Function:               controllers.CreateBookmarkCollection,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/users/me/bookmarks/collections",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListBookmarkCollections,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/users/me/bookmarks/collections/{id}",
+		Method:                 http.MethodPut,
+		Function:               controllers.UpdateBookmarkCollection,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/users/me/bookmarks/collections/{id}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.DeleteBookmarkCollection,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+}
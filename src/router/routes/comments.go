@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesComments = []Route{
+	{
+		URI:                    "/posts/{id}/comments",
+		Method:                 http.MethodPost,
+		Function:               controllers.CreateComment,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/posts/{id}/comments",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListComments,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/comments/{id}",
+		Method:                 http.MethodPut,
+		Function:               controllers.UpdateComment,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/comments/{id}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.DeleteComment,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+}
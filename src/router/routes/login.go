@@ -5,9 +5,17 @@ import (
 	"social-network/src/controllers"
 )
 
-var routeLogin = Route{
-	URI:                    "/login",
-	Method:                 http.MethodPost,
-	Function:               controllers.Login,
-	RequiresAuthentication: false,
+var routesLogin = []Route{
+	{
+		URI:                    "/login",
+		Method:                 http.MethodPost,
+		Function:               controllers.Login,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/login/2fa",
+		Method:                 http.MethodPost,
+		Function:               controllers.LoginTwoFactor,
+		RequiresAuthentication: false,
+	},
 }
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesNotifications = []Route{
+	{
+		URI:                    "/users/me/mentions",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListMentions,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/users/me/notifications",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListNotifications,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
+	},
+	{
+		URI:                    "/users/me/notifications/read",
+		Method:                 http.MethodPost,
+		Function:               controllers.MarkNotificationsRead,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesOAuth = []Route{
+	{
+		URI:                    "/oauth/clients",
+		Method:                 http.MethodPost,
+		Function:               controllers.CreateOAuthClient,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/oauth/clients",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListOAuthClients,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
+	},
+	{
+		URI:                    "/oauth/clients/{id}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.DeleteOAuthClient,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/oauth/authorize",
+		Method:                 http.MethodGet,
+		Function:               controllers.GetOAuthAuthorization,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/oauth/authorize",
+		Method:                 http.MethodPost,
+		Function:               controllers.AuthorizeOAuthClient,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/oauth/token",
+		Method:                 http.MethodPost,
+		Function:               controllers.OAuthToken,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/oauth/introspect",
+		Method:                 http.MethodPost,
+		Function:               controllers.OAuthIntrospect,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/oauth/revoke",
+		Method:                 http.MethodPost,
+		Function:               controllers.OAuthRevoke,
+		RequiresAuthentication: false,
+	},
+}
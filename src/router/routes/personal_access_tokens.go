@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesPersonalAccessTokens = []Route{
+	{
+		URI:                    "/users/me/tokens",
+		Method:                 http.MethodPost,
+		Function:               controllers.CreatePersonalAccessToken,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/users/me/tokens",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListPersonalAccessTokens,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
+	},
+	{
+		URI:                    "/users/me/tokens/{id}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.RevokePersonalAccessToken,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+}
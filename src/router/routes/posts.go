@@ -2,6 +2,7 @@ package routes
 
 import (
 	"net/http"
+	"social-network/src/authentication"
 	"social-network/src/controllers"
 )
 
@@ -11,47 +12,90 @@ var routesPosts = []Route{
 		Method:                 http.MethodPost,
 		Function:               controllers.CreatePost,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
 	},
 	{
 		URI:                    "/posts",
 		Method:                 http.MethodGet,
 		Function:               controllers.ListPosts,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
 	},
 	{
 		URI:                    "/posts/{id}",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetPost,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/posts/{id}/thread",
+		Method:                 http.MethodGet,
+		Function:               controllers.GetThread,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
 	},
 	{
 		URI:                    "/posts/{id}",
 		Method:                 http.MethodPut,
 		Function:               controllers.UpdatePost,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
 	},
 	{
 		URI:                    "/posts/{id}",
 		Method:                 http.MethodDelete,
 		Function:               controllers.DeletePost,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
 	},
 	{
 		URI:                    "/users/{userId}/posts",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetPostsPerUser,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
 	},
 	{
 		URI:                    "/posts/{id}/like",
 		Method:                 http.MethodPost,
 		Function:               controllers.LikePost,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
 	},
 	{
 		URI:                    "/posts/{id}/unlike",
 		Method:                 http.MethodPost,
 		Function:               controllers.UnlikePost,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/posts/{id}/repost",
+		Method:                 http.MethodPost,
+		Function:               controllers.Repost,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/posts/{id}/repost",
+		Method:                 http.MethodDelete,
+		Function:               controllers.Unrepost,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/posts/{id}/likes",
+		Method:                 http.MethodGet,
+		Function:               controllers.GetPostLikes,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/users/{userId}/likes",
+		Method:                 http.MethodGet,
+		Function:               controllers.GetUserLikes,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
 	},
 }
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesReactions = []Route{
+	{
+		URI:                    "/posts/{id}/reactions",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListReactions,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/posts/{id}/reactions/{kind}",
+		Method:                 http.MethodPut,
+		Function:               controllers.AddReaction,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+	{
+		URI:                    "/posts/{id}/reactions/{kind}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.RemoveReaction,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsWrite,
+	},
+}
@@ -12,21 +12,41 @@ type Route struct {
 	Method                 string
 	Function               func(http.ResponseWriter, *http.Request)
 	RequiresAuthentication bool
+	RequiredScope          string
+	RequiredRole           string
 }
 
 func Configure(r *mux.Router) *mux.Router {
 	routes := routesUsers
-	routes = append(routes, routeLogin)
+	routes = append(routes, routesLogin...)
+	routes = append(routes, routesAuthentication...)
+	routes = append(routes, routesSessions...)
+	routes = append(routes, routesTwoFactor...)
+	routes = append(routes, routesPersonalAccessTokens...)
+	routes = append(routes, routesOAuth...)
 	routes = append(routes, routesPosts...)
+	routes = append(routes, routesComments...)
+	routes = append(routes, routesReactions...)
+	routes = append(routes, routesBookmarks...)
+	routes = append(routes, routesTags...)
+	routes = append(routes, routesNotifications...)
 
 	for _, route := range routes {
+		function := http.HandlerFunc(route.Function)
+		if route.RequiredRole != "" {
+			function = middlewares.RequireRole(route.RequiredRole, function)
+		}
+		if route.RequiredScope != "" {
+			function = middlewares.Authorize(route.RequiredScope, function)
+		}
+
 		if route.RequiresAuthentication {
 			r.HandleFunc(route.URI,
 				middlewares.Logger(
-					middlewares.Authenticate(route.Function),
+					middlewares.Authenticate(function),
 				)).Methods(route.Method)
 		} else {
-			r.HandleFunc(route.URI, middlewares.Logger(route.Function)).Methods(route.Method)
+			r.HandleFunc(route.URI, middlewares.Logger(function)).Methods(route.Method)
 		}
 	}
 
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"social-network/src/authentication"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func allRoutes() []Route {
+	var routes []Route
+	for _, group := range [][]Route{
+		routesUsers, routesLogin, routesAuthentication, routesSessions, routesTwoFactor,
+		routesPersonalAccessTokens, routesOAuth, routesPosts, routesComments, routesReactions,
+		routesBookmarks, routesTags, routesNotifications,
+	} {
+		routes = append(routes, group...)
+	}
+	return routes
+}
+
+// A route left without a scope would be open to every token, whatever it
+// was limited to. Any token can still end its own session.
+func TestAuthenticatedRoutesRequireScope(t *testing.T) {
+	for _, route := range allRoutes() {
+		if route.URI == "/auth/logout" {
+			continue
+		}
+		if !route.RequiresAuthentication {
+			if route.RequiredScope != "" || route.RequiredRole != "" {
+				t.Errorf("%s %s checks a scope or role without authentication", route.Method, route.URI)
+			}
+			continue
+		}
+		if route.RequiredScope == "" {
+			t.Errorf("%s %s requires no scope", route.Method, route.URI)
+		} else if error := authentication.ValidateScopes([]string{route.RequiredScope}); error != nil {
+			t.Errorf("%s %s: %v", route.Method, route.URI, error)
+		}
+	}
+}
+
+func TestConfigureRequiresToken(t *testing.T) {
+	router := Configure(mux.NewRouter())
+
+	for _, route := range []struct{ method, target string }{
+		{http.MethodGet, "/posts"},
+		{http.MethodPost, "/posts"},
+		{http.MethodGet, "/users/me/sessions"},
+	} {
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(route.method, route.target, nil))
+		if response.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s status = %d, want %d", route.method, route.target, response.Code, http.StatusUnauthorized)
+		}
+	}
+}
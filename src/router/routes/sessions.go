@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesSessions = []Route{
+	{
+		URI:                    "/users/me/sessions",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListSessions,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
+	},
+	{
+		URI:                    "/users/me/sessions/{id}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.RevokeSession,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+}
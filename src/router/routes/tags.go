@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesTags = []Route{
+	{
+		URI:                    "/tags/trending",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListTrendingTags,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+	{
+		URI:                    "/tags/{tag}/posts",
+		Method:                 http.MethodGet,
+		Function:               controllers.GetTagPosts,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopePostsRead,
+	},
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+	"social-network/src/authentication"
+	"social-network/src/controllers"
+)
+
+var routesTwoFactor = []Route{
+	{
+		URI:                    "/users/me/2fa/setup",
+		Method:                 http.MethodPost,
+		Function:               controllers.SetupTwoFactor,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/users/me/2fa/confirm",
+		Method:                 http.MethodPost,
+		Function:               controllers.ConfirmTwoFactor,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/users/me/2fa/disable",
+		Method:                 http.MethodPost,
+		Function:               controllers.DisableTwoFactor,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+}
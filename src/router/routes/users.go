@@ -2,7 +2,9 @@ package routes
 
 import (
 	"net/http"
+	"social-network/src/authentication"
 	"social-network/src/controllers"
+	"social-network/src/models"
 )
 
 var routesUsers = []Route{
@@ -17,53 +19,84 @@ var routesUsers = []Route{
 		Method:                 http.MethodGet,
 		Function:               controllers.ListUsers,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
 	},
 	{
 		URI:                    "/users/{id}",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetUser,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
 	},
 	{
 		URI:                    "/users/{id}",
 		Method:                 http.MethodPut,
 		Function:               controllers.UpdateUser,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
 	},
 	{
 		URI:                    "/users/{id}",
 		Method:                 http.MethodDelete,
 		Function:               controllers.DeleteUser,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+	},
+	{
+		URI:                    "/users/{id}/role",
+		Method:                 http.MethodPut,
+		Function:               controllers.UpdateUserRole,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
+		RequiredRole:           models.RoleAdmin,
 	},
 	{
 		URI:                    "/users/{userId}/follow",
 		Method:                 http.MethodPost,
 		Function:               controllers.FollowUser,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeFollowsWrite,
 	},
 	{
 		URI:                    "/users/{userId}/unfollow",
 		Method:                 http.MethodPost,
 		Function:               controllers.UnfollowUser,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeFollowsWrite,
+	},
+	{
+		URI:                    "/users/{userId}/block",
+		Method:                 http.MethodPost,
+		Function:               controllers.BlockUser,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeFollowsWrite,
+	},
+	{
+		URI:                    "/users/{userId}/unblock",
+		Method:                 http.MethodPost,
+		Function:               controllers.UnblockUser,
+		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeFollowsWrite,
 	},
 	{
 		URI:                    "/users/{userId}/followers",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetFollowers,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
 	},
 	{
 		URI:                    "/users/{userId}/following",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetFollowing,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersRead,
 	},
 	{
 		URI:                    "/users/update-password",
 		Method:                 http.MethodPost,
 		Function:               controllers.UpdatePassword,
 		RequiresAuthentication: true,
+		RequiredScope:          authentication.ScopeUsersWrite,
 	},
 }
@@ -0,0 +1,110 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"social-network/src/config"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idPrefix  = "$argon2id$"
+	argon2idSaltLen = 16
+	argon2idKeyLen  = 32
+)
+
+type argon2idParams struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+type argon2idHasher struct {
+	params argon2idParams
+}
+
+func newArgon2id() argon2idHasher {
+	return argon2idHasher{argon2idParams{
+		memory:  uint32(config.Argon2Memory),
+		time:    uint32(config.Argon2Time),
+		threads: uint8(config.Argon2Threads),
+	}}
+}
+
+// Hash encodes the password in the PHC string format,
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>.
+func (hasher argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLen)
+	if _, error := rand.Read(salt); error != nil {
+		return "", error
+	}
+
+	params := hasher.params
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2idKeyLen)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.memory,
+		params.time,
+		params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (argon2idHasher) Recognizes(passwordHash string) bool {
+	return strings.HasPrefix(passwordHash, argon2idPrefix)
+}
+
+func (argon2idHasher) Check(passwordHash, password string) error {
+	params, salt, key, error := decodeArgon2id(passwordHash)
+	if error != nil {
+		return error
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (hasher argon2idHasher) Outdated(passwordHash string) bool {
+	params, _, _, error := decodeArgon2id(passwordHash)
+	return error != nil || params != hasher.params
+}
+
+func decodeArgon2id(passwordHash string) (argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return argon2idParams{}, nil, nil, errUnknownHashFormat
+	}
+
+	var version int
+	if _, error := fmt.Sscanf(parts[2], "v=%d", &version); error != nil || version != argon2.Version {
+		return argon2idParams{}, nil, nil, errUnknownHashFormat
+	}
+
+	var params argon2idParams
+	if _, error := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); error != nil {
+		return argon2idParams{}, nil, nil, errUnknownHashFormat
+	}
+
+	salt, error := base64.RawStdEncoding.DecodeString(parts[4])
+	if error != nil {
+		return argon2idParams{}, nil, nil, errUnknownHashFormat
+	}
+
+	key, error := base64.RawStdEncoding.DecodeString(parts[5])
+	if error != nil || len(key) == 0 {
+		return argon2idParams{}, nil, nil, errUnknownHashFormat
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,42 @@
+package security
+
+import (
+	"social-network/src/config"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type bcryptHasher struct {
+	cost int
+}
+
+func newBcrypt() bcryptHasher {
+	return bcryptHasher{config.BcryptCost}
+}
+
+func (hasher bcryptHasher) Hash(password string) (string, error) {
+	passwordHash, error := bcrypt.GenerateFromPassword([]byte(password), hasher.cost)
+	return string(passwordHash), error
+}
+
+func (bcryptHasher) Recognizes(passwordHash string) bool {
+	return strings.HasPrefix(passwordHash, "$2a$") ||
+		strings.HasPrefix(passwordHash, "$2b$") ||
+		strings.HasPrefix(passwordHash, "$2y$")
+}
+
+func (bcryptHasher) Check(passwordHash, password string) error {
+	if error := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); error != nil {
+		if error == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrPasswordMismatch
+		}
+		return error
+	}
+	return nil
+}
+
+func (hasher bcryptHasher) Outdated(passwordHash string) bool {
+	cost, error := bcrypt.Cost([]byte(passwordHash))
+	return error != nil || cost != hasher.cost
+}
@@ -0,0 +1,46 @@
+package security
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"social-network/src/config"
+	"strings"
+)
+
+// IsBreachedPassword looks the password up in a local copy of a breached
+// password list laid out like the k-anonymity range API of Have I Been
+// Pwned: one file per 5 character prefix of the SHA-1 of the password, named
+// <PREFIX>.txt, whose lines are the remaining 35 characters followed by
+// ":<count>". Only the file of the prefix is read, so the full list never
+// has to be loaded into memory. The check is skipped when no list is
+// configured.
+func IsBreachedPassword(password string) (bool, error) {
+	if config.BreachedPasswordsDir == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, error := os.Open(filepath.Join(config.BreachedPasswordsDir, prefix+".txt"))
+	if error != nil {
+		if os.IsNotExist(error) {
+			return false, nil
+		}
+		return false, error
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.EqualFold(strings.SplitN(line, ":", 2)[0], suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
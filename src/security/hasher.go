@@ -0,0 +1,60 @@
+package security
+
+import (
+	"errors"
+	"social-network/src/config"
+)
+
+var ErrPasswordMismatch = errors.New("invalid password")
+
+// Hasher is a password hashing algorithm. Hashes are stored in a
+// self-describing format, so passwords hashed by older algorithms or
+// parameters keep working and can be upgraded on the next login.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Recognizes tells whether the stored hash was produced by this algorithm.
+	Recognizes(passwordHash string) bool
+	Check(passwordHash, password string) error
+	// Outdated tells whether the stored hash uses parameters other than
+	// the ones currently configured.
+	Outdated(passwordHash string) bool
+}
+
+var errUnknownHashFormat = errors.New("unknown password hash format")
+
+func hashers() map[string]Hasher {
+	return map[string]Hasher{
+		"argon2id": newArgon2id(),
+		"bcrypt":   newBcrypt(),
+	}
+}
+
+func currentHasher() (string, Hasher) {
+	name := config.PasswordHasher
+	hasher, ok := hashers()[name]
+	if !ok {
+		name = "argon2id"
+		hasher = newArgon2id()
+	}
+	return name, hasher
+}
+
+func findHasher(passwordHash string) (string, Hasher, error) {
+	for name, hasher := range hashers() {
+		if hasher.Recognizes(passwordHash) {
+			return name, hasher, nil
+		}
+	}
+	return "", nil, errUnknownHashFormat
+}
+
+// NeedsRehash tells whether the stored hash should be replaced by one made
+// with the configured algorithm and parameters.
+func NeedsRehash(passwordHash string) bool {
+	currentName, _ := currentHasher()
+	name, hasher, error := findHasher(passwordHash)
+	if error != nil {
+		return true
+	}
+	return name != currentName || hasher.Outdated(passwordHash)
+}
@@ -0,0 +1,106 @@
+package security
+
+import (
+	"social-network/src/config"
+	"strings"
+	"testing"
+)
+
+// useHasher configures cheap parameters, so tests don't spend seconds
+// hashing.
+func useHasher(t *testing.T, name string) {
+	t.Helper()
+	hasher, memory, time, threads, cost := config.PasswordHasher, config.Argon2Memory, config.Argon2Time, config.Argon2Threads, config.BcryptCost
+	config.PasswordHasher, config.Argon2Memory, config.Argon2Time, config.Argon2Threads, config.BcryptCost = name, 1024, 1, 1, 4
+	t.Cleanup(func() {
+		config.PasswordHasher, config.Argon2Memory, config.Argon2Time, config.Argon2Threads, config.BcryptCost = hasher, memory, time, threads, cost
+	})
+}
+
+func TestHashAndCheckPassword(t *testing.T) {
+	for _, name := range []string{"argon2id", "bcrypt"} {
+		t.Run(name, func(t *testing.T) {
+			useHasher(t, name)
+
+			passwordHash, error := Hash("correct horse")
+			if error != nil {
+				t.Fatal(error)
+			}
+			if foundName, _, _ := findHasher(string(passwordHash)); foundName != name {
+				t.Errorf("hash recognized as %q", foundName)
+			}
+
+			if error := CheckPassword(string(passwordHash), "correct horse"); error != nil {
+				t.Errorf("right password rejected: %v", error)
+			}
+			if error := CheckPassword(string(passwordHash), "wrong horse"); error != ErrPasswordMismatch {
+				t.Errorf("wrong password error = %v, want %v", error, ErrPasswordMismatch)
+			}
+			if NeedsRehash(string(passwordHash)) {
+				t.Error("fresh hash needs rehash")
+			}
+
+			other, _ := Hash("correct horse")
+			if string(other) == string(passwordHash) {
+				t.Error("hashes aren't salted")
+			}
+		})
+	}
+}
+
+func TestArgon2idHashFormat(t *testing.T) {
+	useHasher(t, "argon2id")
+
+	passwordHash, error := Hash("correct horse")
+	if error != nil {
+		t.Fatal(error)
+	}
+	if !strings.HasPrefix(string(passwordHash), "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("unexpected hash %s", passwordHash)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	useHasher(t, "bcrypt")
+	bcryptHash, _ := Hash("correct horse")
+
+	useHasher(t, "argon2id")
+	argon2idHash, _ := Hash("correct horse")
+
+	if !NeedsRehash(string(bcryptHash)) {
+		t.Error("hash of another algorithm doesn't need rehash")
+	}
+	if error := CheckPassword(string(bcryptHash), "correct horse"); error != nil {
+		t.Errorf("hash of another algorithm rejected: %v", error)
+	}
+
+	config.Argon2Time = 2
+	if !NeedsRehash(string(argon2idHash)) {
+		t.Error("hash with outdated parameters doesn't need rehash")
+	}
+	if error := CheckPassword(string(argon2idHash), "correct horse"); error != nil {
+		t.Errorf("hash with outdated parameters rejected: %v", error)
+	}
+
+	if !NeedsRehash("plain text") {
+		t.Error("unknown format doesn't need rehash")
+	}
+}
+
+func TestCheckPasswordRejectsMalformedHashes(t *testing.T) {
+	useHasher(t, "argon2id")
+
+	for _, passwordHash := range []string{
+		"",
+		"plain text",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+	} {
+		if error := CheckPassword(passwordHash, "correct horse"); error == nil {
+			t.Errorf("%q accepted", passwordHash)
+		}
+	}
+}
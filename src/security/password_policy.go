@@ -0,0 +1,149 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"social-network/src/config"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicyError lists every rule of the password policy the password
+// breaks, so the user can fix all of them at once.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (policyError PasswordPolicyError) Error() string {
+	return strings.Join(policyError.Violations, "; ")
+}
+
+// commonPasswords are guessed first by any attacker, whatever their length
+// or character classes.
+var commonPasswords = map[string]bool{
+	"123456": true, "123456789": true, "12345678": true, "1234567890": true,
+	"password": true, "password1": true, "password123": true, "qwerty": true,
+	"qwerty123": true, "qwertyuiop": true, "abc123": true, "111111": true,
+	"123123": true, "iloveyou": true, "admin": true, "welcome": true,
+	"monkey": true, "dragon": true, "letmein": true, "football": true,
+	"baseball": true, "sunshine": true, "princess": true, "superman": true,
+	"trustno1": true, "starwars": true, "whatever": true, "senha": true,
+	"senha123": true, "mudar123": true, "brasil": true, "changeme": true,
+}
+
+// ValidatePassword checks the password against the configured policy. The
+// user inputs, such as nick and e-mail, can't be used as the password and
+// don't count towards its strength.
+func ValidatePassword(password string, userInputs ...string) error {
+	var violations []string
+
+	if length := len([]rune(password)); length < config.PasswordMinLength {
+		violations = append(violations, fmt.Sprintf("password must have at least %d characters", config.PasswordMinLength))
+	}
+
+	for _, input := range userInputs {
+		if input != "" && strings.EqualFold(password, input) {
+			violations = append(violations, "password can't be the same as the nick or e-mail")
+			break
+		}
+	}
+
+	if PasswordScore(password, userInputs...) < config.PasswordMinScore {
+		violations = append(violations, "password is too easy to guess, use a longer mix of words, numbers and symbols")
+	}
+
+	breached, error := IsBreachedPassword(password)
+	if error != nil {
+		return error
+	}
+	if breached {
+		violations = append(violations, "password appeared in a known data breach, choose another one")
+	}
+
+	if len(violations) > 0 {
+		return PasswordPolicyError{violations}
+	}
+	return nil
+}
+
+// PasswordScore estimates how hard the password is to guess, from 0 (trivial)
+// to 4 (very strong), with the same thresholds zxcvbn uses. The guesses are
+// estimated from the character pool and the length left after discounting
+// repeated characters, sequences and user inputs.
+func PasswordScore(password string, userInputs ...string) int {
+	lowered := strings.ToLower(password)
+	if commonPasswords[lowered] {
+		return 0
+	}
+
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		if local := strings.SplitN(input, "@", 2)[0]; len(local) >= 3 {
+			lowered = strings.ReplaceAll(lowered, local, "")
+		}
+		if len(input) >= 3 {
+			lowered = strings.ReplaceAll(lowered, input, "")
+		}
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, character := range password {
+		switch {
+		case unicode.IsLower(character):
+			lower = true
+		case unicode.IsUpper(character):
+			upper = true
+		case unicode.IsDigit(character):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	effectiveLength := 0.0
+	var previous rune
+	for index, character := range []rune(lowered) {
+		switch {
+		case index == 0:
+			effectiveLength++
+		case character == previous:
+			effectiveLength += 0.1
+		case character == previous+1 || character == previous-1:
+			effectiveLength += 0.25
+		default:
+			effectiveLength++
+		}
+		previous = character
+	}
+
+	guesses := math.Pow(float64(pool), effectiveLength)
+	switch {
+	case guesses < 1e3:
+		return 0
+	case guesses < 1e6:
+		return 1
+	case guesses < 1e8:
+		return 2
+	case guesses < 1e10:
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,139 @@
+package security
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"social-network/src/config"
+	"strings"
+	"testing"
+)
+
+func usePolicy(t *testing.T, minLength, minScore int, breachedPasswordsDir string) {
+	t.Helper()
+	length, score, dir := config.PasswordMinLength, config.PasswordMinScore, config.BreachedPasswordsDir
+	config.PasswordMinLength, config.PasswordMinScore, config.BreachedPasswordsDir = minLength, minScore, breachedPasswordsDir
+	t.Cleanup(func() {
+		config.PasswordMinLength, config.PasswordMinScore, config.BreachedPasswordsDir = length, score, dir
+	})
+}
+
+// writeBreachedPasswords lays the passwords out in files named after the
+// prefix of their SHA-1, like the range API of Have I Been Pwned.
+func writeBreachedPasswords(t *testing.T, passwords ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+		file, error := os.OpenFile(filepath.Join(dir, hash[:5]+".txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if error != nil {
+			t.Fatal(error)
+		}
+		if _, error := file.WriteString("0000000000000000000000000000000000A:1\n" + strings.ToLower(hash[5:]) + ":42\n"); error != nil {
+			t.Fatal(error)
+		}
+		file.Close()
+	}
+	return dir
+}
+
+func TestPasswordScore(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"password", 0},
+		{"Password", 0},
+		{"aaaaaaaaaaaa", 0},
+		{"abcdefgh", 1},
+		{"qzkw", 1},
+		{"qzkwm", 2},
+		{"qzkwmx", 3},
+		{"tr0ub4dor", 4},
+		{"correct horse battery staple", 4},
+	}
+
+	for _, test := range tests {
+		if got := PasswordScore(test.password); got != test.want {
+			t.Errorf("PasswordScore(%q) = %d, want %d", test.password, got, test.want)
+		}
+	}
+}
+
+func TestPasswordScoreDiscountsUserInputs(t *testing.T) {
+	if PasswordScore("johnsmith1", "johnsmith", "john@example.com") >= PasswordScore("johnsmith1") {
+		t.Error("nick doesn't lower the score")
+	}
+	if PasswordScore("maria.silva!", "nick", "maria.silva@example.com") >= PasswordScore("maria.silva!") {
+		t.Error("e-mail doesn't lower the score")
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	usePolicy(t, 8, 2, writeBreachedPasswords(t, "Tr0ub4dor&3"))
+
+	tests := []struct {
+		name       string
+		password   string
+		violations []string
+	}{
+		{"strong", "correct horse battery staple", nil},
+		{"short", "x9$Kq", []string{"at least 8 characters"}},
+		{"same as nick", "Zq8!mwPx", []string{"same as the nick or e-mail", "too easy to guess"}},
+		{"common", "password", []string{"too easy to guess"}},
+		{"breached", "Tr0ub4dor&3", []string{"data breach"}},
+		{"several", "abc", []string{"at least 8 characters", "too easy to guess"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			error := ValidatePassword(test.password, "zq8!mwpx", "user@example.com")
+			if test.violations == nil {
+				if error != nil {
+					t.Fatalf("rejected: %v", error)
+				}
+				return
+			}
+
+			policyError, ok := error.(PasswordPolicyError)
+			if !ok {
+				t.Fatalf("error = %v, want a policy error", error)
+			}
+			if len(policyError.Violations) != len(test.violations) {
+				t.Fatalf("violations = %q, want %d", policyError.Violations, len(test.violations))
+			}
+			for index, violation := range test.violations {
+				if !strings.Contains(policyError.Violations[index], violation) {
+					t.Errorf("violation %q doesn't mention %q", policyError.Violations[index], violation)
+				}
+			}
+		})
+	}
+}
+
+func TestIsBreachedPassword(t *testing.T) {
+	usePolicy(t, 8, 2, "")
+	if breached, error := IsBreachedPassword("hunter2"); breached || error != nil {
+		t.Errorf("checked without a list: %v, %v", breached, error)
+	}
+
+	config.BreachedPasswordsDir = writeBreachedPasswords(t, "hunter2", "monkey")
+	for password, want := range map[string]bool{
+		"hunter2": true,
+		"monkey":  true,
+		"Hunter2": false,
+		"hunter3": false,
+	} {
+		breached, error := IsBreachedPassword(password)
+		if error != nil {
+			t.Fatal(error)
+		}
+		if breached != want {
+			t.Errorf("IsBreachedPassword(%q) = %v, want %v", password, breached, want)
+		}
+	}
+}
@@ -1,11 +1,38 @@
 package security
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
 
+// Hash hashes the password with the configured algorithm.
 func Hash(password string) ([]byte, error) {
-	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	_, hasher := currentHasher()
+	passwordHash, error := hasher.Hash(password)
+	return []byte(passwordHash), error
 }
 
+// CheckPassword verifies the password against a hash made by any of the
+// supported algorithms.
 func CheckPassword(passwordHash, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
+	_, hasher, error := findHasher(passwordHash)
+	if error != nil {
+		return error
+	}
+	return hasher.Check(passwordHash, password)
+}
+
+func RandomToken(size int) (string, error) {
+	buffer := make([]byte, size)
+	if _, error := rand.Read(buffer); error != nil {
+		return "", error
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
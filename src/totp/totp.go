@@ -0,0 +1,77 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	digits = 6
+	period = 30
+	skew   = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func GenerateSecret() (string, error) {
+	buffer := make([]byte, 20)
+	if _, error := rand.Read(buffer); error != nil {
+		return "", error
+	}
+	return encoding.EncodeToString(buffer), nil
+}
+
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(digits))
+	params.Set("period", fmt.Sprint(period))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Validate checks the code against the current time step and its neighbours,
+// returning the matched step so callers can refuse to accept it twice.
+func Validate(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != digits {
+		return 0, false
+	}
+
+	key, error := encoding.DecodeString(strings.ToUpper(secret))
+	if error != nil {
+		return 0, false
+	}
+
+	step := now.Unix() / period
+	for offset := int64(-skew); offset <= skew; offset++ {
+		expected := generate(key, step+offset)
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step + offset, true
+		}
+	}
+	return 0, false
+}
+
+func generate(key []byte, step int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
@@ -0,0 +1,124 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the test vectors of RFC 6238,
+// "12345678901234567890" in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, test := range tests {
+		step, valid := Validate(rfcSecret, test.code, time.Unix(test.unix, 0))
+		if !valid {
+			t.Errorf("code %s rejected at %d", test.code, test.unix)
+			continue
+		}
+		if step != test.unix/period {
+			t.Errorf("step = %d, want %d", step, test.unix/period)
+		}
+	}
+}
+
+func TestValidateAcceptsNeighbourSteps(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	key, _ := encoding.DecodeString(rfcSecret)
+	step := now.Unix() / period
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code := generate(key, step+offset)
+		matched, valid := Validate(rfcSecret, code, now)
+
+		if wantValid := offset >= -skew && offset <= skew; valid != wantValid {
+			t.Errorf("offset %d: valid = %v, want %v", offset, valid, wantValid)
+		}
+		if valid && matched != step+offset {
+			t.Errorf("offset %d: step = %d, want %d", offset, matched, step+offset)
+		}
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"short code", rfcSecret, "28708"},
+		{"long code", rfcSecret, "2870820"},
+		{"wrong code", rfcSecret, "287083"},
+		{"invalid secret", "not base32!", "287082"},
+	}
+
+	for _, test := range tests {
+		if _, valid := Validate(test.secret, test.code, now); valid {
+			t.Errorf("%s: accepted", test.name)
+		}
+	}
+
+	if _, valid := Validate(strings.ToLower(rfcSecret), " 287082 ", now); !valid {
+		t.Error("lower-case secret or padded code rejected")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, error := GenerateSecret()
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	key, error := encoding.DecodeString(secret)
+	if error != nil {
+		t.Fatal(error)
+	}
+	if len(key) != 20 {
+		t.Errorf("key has %d bytes, want 20", len(key))
+	}
+
+	if other, _ := GenerateSecret(); other == secret {
+		t.Error("secrets repeat")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri, error := url.Parse(URI("social network", "user@example.com", rfcSecret))
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	if uri.Path != "/social network:user@example.com" {
+		t.Errorf("label = %q", uri.Path)
+	}
+
+	query := uri.Query()
+	for key, want := range map[string]string{
+		"secret":    rfcSecret,
+		"issuer":    "social network",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	} {
+		if query.Get(key) != want {
+			t.Errorf("%s = %q, want %q", key, query.Get(key), want)
+		}
+	}
+}
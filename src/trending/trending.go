@@ -0,0 +1,50 @@
+package trending
+
+import (
+	"log"
+	"social-network/src/config"
+	"social-network/src/database"
+	"social-network/src/repositories"
+	"time"
+)
+
+// tagsKept is how many trending tags are stored for each window.
+const tagsKept = 100
+
+// Start recomputes the trending tags of every configured window right away
+// and then every config.TrendingRefreshInterval, in the background. Every API
+// instance runs it, which only repeats work since recomputing replaces the
+// previous result.
+func Start() {
+	go func() {
+		refresh()
+
+		ticker := time.NewTicker(config.TrendingRefreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refresh()
+		}
+	}()
+}
+
+// halfLife is how long a use of a tag takes to weigh half as much in the
+// window, so the score favours what is being used right now.
+func halfLife(window time.Duration) time.Duration {
+	return window / 4
+}
+
+func refresh() {
+	db, error := database.Connect()
+	if error != nil {
+		log.Printf("\nrecomputing trending tags: %v", error)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.NewRepositoryTags(db)
+	for _, window := range config.TrendingWindows {
+		if error := repository.RecomputeTrending(window, halfLife(window), tagsKept); error != nil {
+			log.Printf("\nrecomputing trending tags of the last %s: %v", window, error)
+		}
+	}
+}